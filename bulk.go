@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-
-	"github.com/Ronmi/sdm/driver"
 )
 
 // Bulk represents batch operations
@@ -50,8 +48,15 @@ func (b *bulkinfo) Len() int {
 	return len(b.data)
 }
 
+// bulkWriter is implemented by bulk operations filling generated values back
+// to added data, which is done after successful execution
+type bulkWriter interface {
+	writeBack()
+}
+
 type bulkInsert struct {
 	*bulkinfo
+	stamped []interface{} // data with timestamps of last Make
 }
 
 func (b *bulkInsert) Make() ([]string, [][]interface{}) {
@@ -62,15 +67,18 @@ func (b *bulkInsert) Make() ([]string, [][]interface{}) {
 	data := b.data[0]
 	cols := b.m.ColIns(data)
 	l := len(cols)
-	placeholders := make([]string, 0, l)
-	hds := b.m.HolderIns(data)
+	placeholders := make([]string, 0, len(b.data))
 	vals := make([]interface{}, 0, len(b.data)*l)
 
-	paramstr := "(" + strings.Join(hds, ",") + ")"
-
-	for _, v := range b.data {
-		placeholders = append(placeholders, paramstr)
-		i := b.m.ValIns(v)
+	info := b.m.getInfo(b.typ)
+	now := b.m.now()
+	p := b.m.Placeholders()
+	b.stamped = make([]interface{}, len(b.data))
+	for x, v := range b.data {
+		hds := b.m.holder(info, true, p)
+		placeholders = append(placeholders, "("+strings.Join(hds, ",")+")")
+		b.stamped[x] = b.m.stamp(info, v, now, true)
+		i := b.m.ValIns(b.stamped[x])
 		vals = append(vals, i...)
 	}
 
@@ -84,8 +92,19 @@ func (b *bulkInsert) Make() ([]string, [][]interface{}) {
 	return []string{qstr}, [][]interface{}{vals}
 }
 
+// writeBack fills timestamps generated by last Make back to added data
+func (b *bulkInsert) writeBack() {
+	info := b.m.getInfo(b.typ)
+	for x, v := range b.data {
+		if x < len(b.stamped) {
+			b.m.writeBack(info, v, b.stamped[x], info.Created, info.Updated)
+		}
+	}
+}
+
 type bulkDelete struct {
 	*bulkinfo
+	hard bool // always DELETE even if soft delete is supported
 }
 
 func (b *bulkDelete) Make() ([]string, [][]interface{}) {
@@ -93,25 +112,37 @@ func (b *bulkDelete) Make() ([]string, [][]interface{}) {
 		return []string{""}, [][]interface{}{}
 	}
 
-	data := b.data[0]
-	cols := b.m.Col(data, driver.QWhere)
-	l := len(cols)
-	hds := b.m.Holder(data)
+	info := b.m.getInfo(b.typ)
 	placeholders := make([]string, 0, len(b.data))
-	com := make([]string, l)
-	vals := make([]interface{}, 0, len(b.data)*l)
-
-	for k, v := range cols {
-		com[k] = v + "=" + hds[k]
+	vals := make([]interface{}, 0, len(b.data)*len(info.Fields))
+
+	// SET clause of soft delete comes first
+	p := b.m.Placeholders()
+	soft := info.SoftDel != "" && !b.hard
+	var set string
+	var val interface{}
+	if soft {
+		set, val = b.m.softDelSet(info, b.m.now(), p)
 	}
-	paramstr := "(" + strings.Join(com, " AND ") + ")"
 
 	for _, v := range b.data {
-		placeholders = append(placeholders, paramstr)
-		i := b.m.Val(v)
+		// conditions might differ between rows because of NULL
+		cond, i := b.m.matchCond(v, p)
+		placeholders = append(placeholders, "("+cond+")")
 		vals = append(vals, i...)
 	}
 
+	if soft {
+		qstr := fmt.Sprintf(
+			`UPDATE %s SET %s WHERE %s`,
+			b.m.drv.Quote(b.table),
+			set,
+			strings.Join(placeholders, " OR "),
+		)
+
+		return []string{qstr}, [][]interface{}{append([]interface{}{val}, vals...)}
+	}
+
 	qstr := fmt.Sprintf(
 		`DELETE FROM %s WHERE %s`,
 		b.m.drv.Quote(b.table),
@@ -99,4 +99,50 @@ func TestBulk(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("DeleteNull", func(t *testing.T) {
+		db := newdb(t)
+		m := New(db, "sqlite3:time=int")
+		m.Reg(testnullable{})
+		if err := m.CreateTables(); err != nil {
+			t.Fatalf("cannot create table: %s", err)
+		}
+
+		name := "name"
+		d1 := testnullable{ID: 1, Name: &name}
+		d2 := testnullable{ID: 2}
+		for _, d := range []testnullable{d1, d2} {
+			if _, err := m.Insert(d); err != nil {
+				t.Fatalf("cannot insert: %s", err)
+			}
+		}
+
+		b := m.BulkDelete(d1)
+		b.Add(d1, d2)
+
+		expectStr := `DELETE FROM "testnullable" WHERE ("testnullable"."id"=? AND "testnullable"."name"=? AND "testnullable"."t" IS NULL) OR ("testnullable"."id"=? AND "testnullable"."name" IS NULL AND "testnullable"."t" IS NULL)`
+		qstr, vals := b.Make()
+		if qstr[0] != expectStr {
+			t.Errorf("Expect bulk delete generates [%s], got [%s]", expectStr, qstr)
+		}
+		if l := len(vals[0]); l != 3 {
+			t.Errorf("Expected to get 3 vals, get %d", l)
+		}
+
+		if _, err := m.RunBulk(b); err != nil {
+			t.Fatalf("cannot run bulk delete: %s", err)
+		}
+		var cnt int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM testnullable`).Scan(&cnt); err != nil {
+			t.Fatalf("cannot count: %s", err)
+		}
+		if cnt != 0 {
+			t.Errorf("expected all rows are deleted, got %d", cnt)
+		}
+
+		m.StrictDelete = true
+		if _, err := m.RunBulk(b); err != ErrNoRowsAffected {
+			t.Errorf("expected ErrNoRowsAffected in strict mode, got %v", err)
+		}
+	})
 }
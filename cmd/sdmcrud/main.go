@@ -324,6 +324,25 @@ func getCol(k, tags string) (col string) {
 	return col
 }
 
+// getVer finds version field (tagged with "ver") of the struct
+func getVer(info readstruct.Info) (name, col string) {
+	for _, f := range info.Fields {
+		tagInfo := regSDMTag.FindStringSubmatch(f.Tags)
+		if len(tagInfo) != 2 {
+			continue
+		}
+
+		vals := strings.Split(tagInfo[1], ",")
+		for _, v := range vals[1:] {
+			if v == "ver" {
+				return f.Name, vals[0]
+			}
+		}
+	}
+
+	return
+}
+
 func genSelectBy(buf *bytes.Buffer, info readstruct.Info, typeName, name, k string) (stmt string, code Code) {
 	var f readstruct.Field
 	for _, x := range info.Fields {
@@ -385,22 +404,63 @@ func genUpdate(info readstruct.Info, pkg, name, pk string) (buf *bytes.Buffer, s
 	col := getCol(pk, f.Tags)
 
 	buf = &bytes.Buffer{}
+	verField, verCol := getVer(info)
+	if verField == "" {
+		fmt.Fprintf(
+			buf,
+			`func (r *%sRepo) Update%s(data *%s) (err error) {
+	stmt := r.m.Stmt(r.stmtUpdate)
+	args := append(r.m.Val(data), data.%s)
+	_, err = stmt.Exec(args...)
+	return
+}
+
+`,
+			name, name, typeName,
+			pk,
+		)
+		stmts = map[string]Code{
+			"stmtUpdate": Code{
+				code:  `"UPDATE %table% SET %combined% WHERE ` + col + `=?"`,
+				quote: "QUpdate",
+			},
+		}
+
+		return
+	}
+
+	// optimistic locking: write increased version, but match current one
 	fmt.Fprintf(
 		buf,
 		`func (r *%sRepo) Update%s(data *%s) (err error) {
 	stmt := r.m.Stmt(r.stmtUpdate)
-	args := append(r.m.Val(data), data.%s)
-	_, err = stmt.Exec(args...)
+	x := *data
+	x.%s++
+	args := append(r.m.Val(&x), data.%s, data.%s)
+	res, err := stmt.Exec(args...)
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		return sdm.ErrStaleObject
+	}
+	data.%s = x.%s
 	return
 }
 
 `,
 		name, name, typeName,
-		pk,
+		verField,
+		pk, verField,
+		verField, verField,
 	)
 	stmts = map[string]Code{
 		"stmtUpdate": Code{
-			code:  `"UPDATE %table% SET %combined% WHERE ` + col + `=?"`,
+			code:  `"UPDATE %table% SET %combined% WHERE ` + col + `=? AND ` + verCol + `=?"`,
 			quote: "QUpdate",
 		},
 	}
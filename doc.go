@@ -9,7 +9,7 @@
 //
 //     `sdm:"column_name,property,property,..."`
 //
-// SDM supports 4 properties:
+// SDM supports 8 properties:
 //
 //   - ai:    This column is auto increased. SDM will not pass value to
 //            DB when inserting.
@@ -19,9 +19,68 @@
 //            to single unique key. Same rules applies to indexes. See
 //            example code for how to use it.
 //   - idx_:  Specify search index.
+//   - ver:   Integer version column for optimistic locking. Update and
+//            UpdateByPK increase it and check it in WHERE clause, and
+//            return ErrStaleObject if the row is modified by others.
+//   - softdel: *time.Time column for soft delete. Delete marks rows as
+//            deleted instead, and LoadSimple/Find skip them. Use
+//            HardDelete and FindWithDeleted to bypass.
+//   - created: time.Time or *time.Time column filled with Manager.Now
+//            when inserting (if not set), never updated.
+//   - updated: time.Time or *time.Time column filled with Manager.Now
+//            when inserting or updating.
 //
-// As you cn see, SDM does not support foreign key, and possibly never
-// support it. ORM is suggested if you need foreign key mapping.
+// Column definition used by CreateTables can be tuned with following options:
+//
+//   - type=X:    Use X as column type, like "type=DECIMAL(10,2)".
+//   - size=N:    Size of string or binary column, like VARCHAR(N).
+//   - default=X: Default value in SQL syntax, like "default=''".
+//   - null, notnull: Override nullability, which depends on pointer-ness
+//            by default.
+//   - comment=X: Column comment, quote it if it contains comma:
+//            "comment='name, in full'". Not supported by sqlite.
+//   - was=X:     Previous column name. PlanMigration and CreateTablesNotExist
+//            rename the column instead of recreating it. Use RegisterWas
+//            for previous table names.
+//
+// Anonymous embedded structs (or pointer to struct) without SDM tag are
+// flattened, so common fields can be shared between tables:
+//
+//     type BaseModel struct {
+//         ID int `sdm:"id,ai"`
+//     }
+//     type User struct {
+//         BaseModel
+//         Name string `sdm:"name"`
+//     }
+//
+// Nil embedded pointers are read as NULL, and allocated when scanning.
+//
+// Named struct fields can also be flattened with "inline" property. The
+// column name part becomes prefix of columns (and indexes) in the sub-struct:
+//
+//     type Address struct {
+//         Street string `sdm:"street"`
+//         City   string `sdm:"city,idx_city"`
+//     }
+//     type Order struct {
+//         ID       int     `sdm:"id,ai"`
+//         Shipping Address `sdm:"ship_,inline"` // ship_street, ship_city
+//         Billing  Address `sdm:"bill_,inline"` // bill_street, bill_city
+//     }
+//
+// Foreign key constraints can be declared with "fk_NAME=table.col" property,
+// and referential actions with "ondelete=X" and "onupdate=X", where X is one
+// of cascade, restrict, set_null, set_default or no_action:
+//
+//     type Member struct {
+//         ID      int `sdm:"id,ai"`
+//         GroupID int `sdm:"group_id,fk_member_group=group.id,ondelete=cascade"`
+//     }
+//
+// Columns using same NAME form a composite foreign key. CreateTables creates
+// referenced tables first. Note that SDM only creates the constraint, it does
+// not map relations between structs. ORM is suggested if you need that.
 //
 // SDM should be safe to use in concurrent environment. Read/write to
 // internal data are lock-protected.
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // Driver is used to generate SQL syntax
@@ -22,6 +23,9 @@ type Driver interface {
 	// CreateTableNotExist creates table only if table does not exist
 	CreateTableNotExist(db *sql.DB, name string, typ reflect.Type, cols []Column, indexes []Index) (sql.Result, error)
 
+	// DropTable drops table
+	DropTable(db *sql.DB, name string) (sql.Result, error)
+
 	// Quote quotes identifiers like table name or column name
 	Quote(name string) string
 
@@ -42,6 +46,83 @@ type Driver interface {
 	GetValuer(field reflect.Value) (ret sqlDriver.Valuer, ok bool)
 }
 
+// TimeNormalizer is an optional interface for drivers storing time with limited
+// precision or in specific time zone.
+//
+// NormalizeTime returns the value which will be read back after t is written,
+// so timestamps generated by SDM are filled back to struct identically.
+type TimeNormalizer interface {
+	NormalizeTime(t time.Time) time.Time
+}
+
+// Binder is an optional interface for drivers using numbered placeholders like
+// "$1", GetPlaceholder is ignored if it is implemented.
+type Binder interface {
+	// Placeholder returns placeholder of n-th parameter, n starts from 1
+	Placeholder(n int) string
+}
+
+// Returner is an optional interface for drivers which cannot get value of auto
+// increment column by sql.Result.LastInsertId, like PostgreSQL.
+type Returner interface {
+	// Returning generates clause appended to INSERT statement, which returns
+	// value of the column, like ` RETURNING "id"`
+	Returning(col string) string
+}
+
+// Inspector is an optional interface for drivers which can read schema of live
+// database.
+//
+// Index names might differ from the ones used in CREATE TABLE, if database
+// does not keep them (primary key in sqlite for example). Referential action
+// "NO ACTION", the default one, is reported as empty string.
+type Inspector interface {
+	// Tables lists tables in current database, sorted by name
+	Tables(db *sql.DB) ([]string, error)
+
+	// Columns lists columns of the table, in the order they are defined
+	Columns(db *sql.DB, table string) ([]ColumnInfo, error)
+
+	// Indexes lists indexes and foreign keys of the table
+	Indexes(db *sql.DB, table string) ([]Index, error)
+
+	// Expect returns schema CreateTable creates, in the form Columns and
+	// Indexes report. Default values are not included.
+	Expect(typ reflect.Type, cols []Column, indexes []Index) ([]ColumnInfo, []Index)
+}
+
+// Migrator is an optional interface for drivers which can generate DDL to
+// migrate existing tables, see Manager.PlanMigration in package sdm.
+//
+// Methods altering tables return ok = false if the change cannot be done by
+// ALTER TABLE, and the table is rebuilt if the driver implements Rebuilder.
+type Migrator interface {
+	// CreateTableSQL generates statement used in CreateTable
+	CreateTableSQL(name string, typ reflect.Type, cols []Column, indexes []Index) string
+
+	AddColumn(table string, typ reflect.Type, col Column, indexes []Index) (stmts []string, ok bool)
+	DropColumn(table, col string) (stmts []string, ok bool)
+	ModifyColumn(table string, typ reflect.Type, col Column, indexes []Index) (stmts []string, ok bool)
+	AddIndex(table string, typ reflect.Type, cols []Column, idx Index) (stmts []string, ok bool)
+
+	// DropIndex drops index reported by Inspector
+	DropIndex(table string, idx Index) (stmts []string, ok bool)
+
+	// RenameTable and RenameColumn must be supported, since rebuilding table
+	// cannot keep data of renamed column. Definition of column is updated
+	// along with renaming if possible.
+	RenameTable(from, to string) []string
+	RenameColumn(table, from string, typ reflect.Type, col Column, indexes []Index) []string
+}
+
+// Rebuilder is an optional interface for Migrators which cannot do some
+// changes with ALTER TABLE.
+type Rebuilder interface {
+	// RebuildTable recreates table with new definition, data of columns in
+	// keep are copied.
+	RebuildTable(table string, typ reflect.Type, cols []Column, indexes []Index, keep []string) []string
+}
+
 // DriverFactory represents a function to create driver.
 type DriverFactory func(params map[string]string) Driver
 
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Ronmi/sdm/driver"
 )
@@ -40,22 +41,20 @@ type drv struct {
 	driver.Stub
 }
 
-func (d *drv) getType(typ reflect.Type, name string, indexes []driver.Index) string {
+func (d *drv) getType(typ reflect.Type, name string, size int, indexes []driver.Index) string {
 	t := driver.ElementType(typ)
 
 	// check []byte first
 	switch typ.Kind() {
 	case reflect.Array, reflect.Slice:
 		if t.Kind() == reflect.Uint8 {
+			if size > 0 {
+				return "VARBINARY(" + strconv.Itoa(size) + ")"
+			}
 			return "BLOB"
 		}
 	}
 
-	postfix := ""
-	if typ.Kind() != reflect.Ptr {
-		postfix = " NOT NULL"
-	}
-
 	if def, ok := typeMap[t.Kind()]; ok {
 		if t.Kind() == reflect.String {
 			has := false
@@ -65,28 +64,119 @@ func (d *drv) getType(typ reflect.Type, name string, indexes []driver.Index) str
 					break
 				}
 			}
-			if has {
+			switch {
+			case size > 0:
+				def = "VARCHAR(" + strconv.Itoa(size) + ")"
+			case has:
 				def = "VARCHAR(" + d.stringKeySize + ")"
 			}
 			def += " CHARACTER SET " + d.charset + " COLLATE " + d.collate
 		}
-		return def + postfix
+		return def
 	}
 
 	if driver.IsTime(t) {
-		return "TIMESTAMP" + postfix
+		return "TIMESTAMP"
 	}
 
 	panic("sdm: driver: mysql: unsupported type " + t.String())
 }
 
+// columnDef generates column definition without constraints and comment
+func (d *drv) columnDef(typ reflect.Type, c driver.Column, indexes []driver.Index) string {
+	ft := typ.FieldByIndex(c.Index).Type
+	t := c.Type
+	if t == "" {
+		t = d.getType(ft, c.Name, c.Size, indexes)
+	}
+
+	return quote(c.Name) + ` ` + t + c.Options(ft)
+}
+
+func quoteString(str string) string {
+	return "'" + strings.Replace(strings.Replace(str, `\`, `\\`, -1), "'", "''", -1) + "'"
+}
+
+// fullColumnDef generates column definition with auto increment and comment
+func (d *drv) fullColumnDef(typ reflect.Type, c driver.Column, indexes []driver.Index) string {
+	def := d.columnDef(typ, c, indexes)
+	if c.AI {
+		def += " AUTO_INCREMENT"
+	}
+	if c.Comment != "" {
+		def += " COMMENT " + quoteString(c.Comment)
+	}
+	return def
+}
+
+// indexDef generates index definition used in CREATE TABLE and ALTER TABLE
+func (d *drv) indexDef(typ reflect.Type, cols []driver.Column, i driver.Index) (def string) {
+	quoted := make([]string, len(i.Cols))
+	for k, v := range i.Cols {
+		quoted[k] = quote(v)
+		if i.Type == driver.IndexTypeForeign {
+			continue
+		}
+		for _, c := range cols {
+			if c.Name != v {
+				continue
+			}
+			if c.Type != "" || c.Size > 0 {
+				// not a BLOB, no need to specify key length
+				break
+			}
+
+			ki := typ.FieldByIndex(c.Index).Type.Kind()
+			kie := ki
+			if ki == reflect.Array || ki == reflect.Slice || ki == reflect.Ptr {
+				kie = typ.FieldByIndex(c.Index).Type.Elem().Kind()
+			}
+			if ki == reflect.Array || ki == reflect.Slice {
+				if kie == reflect.Uint8 {
+					quoted[k] += "(" + d.blobKeySize + ")"
+				}
+			}
+			break
+		}
+	}
+
+	switch i.Type {
+	case driver.IndexTypeIndex:
+		def = fmt.Sprintf(
+			"INDEX %s (%s)",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+		)
+	case driver.IndexTypePrimary:
+		def = fmt.Sprintf(
+			"CONSTRAINT %s PRIMARY KEY (%s)",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+		)
+	case driver.IndexTypeUnique:
+		def = fmt.Sprintf(
+			"CONSTRAINT %s UNIQUE KEY (%s)",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+		)
+	case driver.IndexTypeForeign:
+		def = fmt.Sprintf(
+			"CONSTRAINT %s FOREIGN KEY (%s) %s",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+			i.Ref.Clause(quote),
+		)
+	}
+
+	return
+}
+
 func (d *drv) createTableColumnSQL(typ reflect.Type, cols []driver.Column, indexes []driver.Index) string {
 	ret := make([]string, 0, len(cols)+len(indexes))
 
 	var aiIndex *driver.Index
 
 	for _, c := range cols {
-		def := quote(c.Name) + ` ` + d.getType(typ.Field(c.ID).Type, c.Name, indexes)
 		if c.AI {
 			hasPK := false
 			for _, i := range indexes {
@@ -102,9 +192,8 @@ func (d *drv) createTableColumnSQL(typ reflect.Type, cols []driver.Column, index
 					Cols: []string{c.Name},
 				}
 			}
-			def += " AUTO_INCREMENT"
 		}
-		ret = append(ret, def)
+		ret = append(ret, d.fullColumnDef(typ, c, indexes))
 	}
 
 	idxes := make([]driver.Index, 0, len(indexes)+1)
@@ -113,66 +202,25 @@ func (d *drv) createTableColumnSQL(typ reflect.Type, cols []driver.Column, index
 	}
 	idxes = append(idxes, indexes...)
 	for _, i := range idxes {
-		var def string
-		quoted := make([]string, len(i.Cols))
-		for k, v := range i.Cols {
-			quoted[k] = quote(v)
-			for _, c := range cols {
-				if c.Name != v {
-					continue
-				}
-
-				ki := typ.Field(c.ID).Type.Kind()
-				kie := ki
-				if ki == reflect.Array || ki == reflect.Slice || ki == reflect.Ptr {
-					kie = typ.Field(c.ID).Type.Elem().Kind()
-				}
-				if ki == reflect.Array || ki == reflect.Slice {
-					if kie == reflect.Uint8 {
-						quoted[k] += "(" + d.blobKeySize + ")"
-					}
-				}
-				break
-			}
-		}
-
-		switch i.Type {
-		case driver.IndexTypeIndex:
-			def = fmt.Sprintf(
-				"INDEX %s (%s)",
-				quote(i.Name),
-				strings.Join(quoted, ","),
-			)
-		case driver.IndexTypePrimary:
-			def = fmt.Sprintf(
-				"CONSTRAINT %s PRIMARY KEY (%s)",
-				quote(i.Name),
-				strings.Join(quoted, ","),
-			)
-		case driver.IndexTypeUnique:
-			def = fmt.Sprintf(
-				"CONSTRAINT %s UNIQUE KEY (%s)",
-				quote(i.Name),
-				strings.Join(quoted, ","),
-			)
-		}
-
-		ret = append(ret, def)
+		ret = append(ret, d.indexDef(typ, cols, i))
 	}
 
 	return strings.Join(ret, ",")
 }
 
-func (d *drv) CreateTable(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
-	qstr := fmt.Sprintf(
+// CreateTableSQL implements driver.Migrator
+func (d *drv) CreateTableSQL(name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) string {
+	return fmt.Sprintf(
 		"CREATE TABLE %s (%s) DEFAULT CHARACTER SET %s,DEFAULT COLLATE %s",
 		quote(name),
 		d.createTableColumnSQL(typ, cols, indexes),
 		d.charset,
 		d.collate,
 	)
+}
 
-	return db.Exec(qstr)
+func (d *drv) CreateTable(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
+	return db.Exec(d.CreateTableSQL(name, typ, cols, indexes))
 }
 
 func (d *drv) CreateTableNotExist(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
@@ -204,6 +252,15 @@ func (s *drv) GetValuer(field reflect.Value) (ret sqlDriver.Valuer, ok bool) {
 	}
 	return s.Stub.GetValuer(field)
 }
+
+// NormalizeTime implements driver.TimeNormalizer
+//
+// TIMESTAMP columns created by SDM have no fraction part, and time is read as
+// UTC unless parseTime and loc are set in DSN.
+func (s *drv) NormalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
 func init() {
 	driver.RegisterDriver("mysql", func(p map[string]string) driver.Driver {
 		charset := "utf8"
@@ -17,6 +17,7 @@ type testSQLCase struct {
 }
 
 func TestColumnSQL(t *testing.T) {
+	yes, no := true, false
 	cases := []testSQLCase{
 		{
 			t: reflect.TypeOf(struct {
@@ -24,8 +25,8 @@ func TestColumnSQL(t *testing.T) {
 				T  time.Time
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "t"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "t"},
 			},
 			idx:  []driver.Index{},
 			qstr: "`id` BIGINT NOT NULL,`t` TIMESTAMP NOT NULL",
@@ -40,11 +41,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypePrimary, Name: "test_pk", Cols: []string{"id"}},
@@ -62,11 +63,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypePrimary, Name: "test_pk", Cols: []string{"id"}},
@@ -84,11 +85,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypeUnique, Name: "birth", Cols: []string{"y", "m", "d"}},
@@ -106,12 +107,12 @@ func TestColumnSQL(t *testing.T) {
 				Col5 []byte    `driver:"c5"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "c1"},
-				{ID: 2, AI: false, Name: "c2"},
-				{ID: 3, AI: false, Name: "c3"},
-				{ID: 4, AI: false, Name: "c4"},
-				{ID: 5, AI: false, Name: "c5"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "c1"},
+				{Index: []int{2}, AI: false, Name: "c2"},
+				{Index: []int{3}, AI: false, Name: "c3"},
+				{Index: []int{4}, AI: false, Name: "c4"},
+				{Index: []int{5}, AI: false, Name: "c5"},
 			},
 			idx:  []driver.Index{},
 			qstr: "`id` BIGINT NOT NULL,`c1` DOUBLE NOT NULL,`c2` BIT(1) NOT NULL,`c3` TIMESTAMP NOT NULL,`c4` TEXT CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL,`c5` BLOB",
@@ -126,11 +127,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypeIndex, Name: "birth", Cols: []string{"y", "m", "d"}},
@@ -147,11 +148,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name,idx_myname"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypeIndex, Name: "myname", Cols: []string{"name"}},
@@ -159,6 +160,68 @@ func TestColumnSQL(t *testing.T) {
 			qstr: "`id` BIGINT NOT NULL AUTO_INCREMENT,`y` BIGINT NOT NULL,`m` BIGINT NOT NULL,`d` BIGINT NOT NULL,`name` VARCHAR(256) CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL,CONSTRAINT `_pk` PRIMARY KEY (`id`),INDEX `myname` (`name`)",
 			msg:  "well-defined struct with auto increment and index key on string column",
 		},
+		{
+			t: reflect.TypeOf(struct {
+				ID   int `driver:"id,ai"`
+				Addr struct {
+					Street string `driver:"street"`
+					Zip    []byte `driver:"zip,idx_zip"`
+				} `driver:"addr_,inline"`
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1, 0}, AI: false, Name: "addr_street"},
+				{Index: []int{1, 1}, AI: false, Name: "addr_zip"},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypeIndex, Name: "addr_zip", Cols: []string{"addr_zip"}},
+			},
+			qstr: "`id` BIGINT NOT NULL AUTO_INCREMENT,`addr_street` TEXT CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL,`addr_zip` BLOB,CONSTRAINT `_pk` PRIMARY KEY (`id`),INDEX `addr_zip` (`addr_zip`(2048))",
+			msg:  "nested struct fields",
+		},
+		{
+			t: reflect.TypeOf(struct {
+				ID    int
+				Name  string
+				Price float64
+				Memo  *string
+				Data  []byte
+				T     time.Time
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id", Comment: "it's id"},
+				{Index: []int{1}, AI: false, Name: "name", Size: 64, Default: "''"},
+				{Index: []int{2}, AI: false, Name: "price", Type: "DECIMAL(10,2)", Nullable: &yes},
+				{Index: []int{3}, AI: false, Name: "memo", Nullable: &no},
+				{Index: []int{4}, AI: false, Name: "data", Size: 16},
+				{Index: []int{5}, AI: false, Name: "t", Default: "CURRENT_TIMESTAMP"},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypeIndex, Name: "data", Cols: []string{"data"}},
+			},
+			qstr: "`id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'it''s id',`name` VARCHAR(64) CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL DEFAULT '',`price` DECIMAL(10,2) NULL,`memo` TEXT CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL,`data` VARBINARY(16),`t` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,CONSTRAINT `_pk` PRIMARY KEY (`id`),INDEX `data` (`data`)",
+			msg:  "column options",
+		},
+		{
+			t: reflect.TypeOf(struct {
+				ID    int
+				Owner int
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "owner"},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypeForeign, Name: "owner", Cols: []string{"owner"}, Ref: &driver.ForeignKey{
+					Table:    "user",
+					Cols:     []string{"id"},
+					OnDelete: "SET NULL",
+					OnUpdate: "CASCADE",
+				}},
+			},
+			qstr: "`id` BIGINT NOT NULL AUTO_INCREMENT,`owner` BIGINT NOT NULL,CONSTRAINT `_pk` PRIMARY KEY (`id`),CONSTRAINT `owner` FOREIGN KEY (`owner`) REFERENCES `user` (`id`) ON DELETE SET NULL ON UPDATE CASCADE",
+			msg:  "foreign key",
+		},
 	}
 
 	for _, c := range cases {
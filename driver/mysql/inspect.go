@@ -0,0 +1,262 @@
+package mysql
+
+import (
+	"database/sql"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// normalizeAction converts referential action reported by information_schema.
+// RESTRICT is same as NO ACTION in MySQL, and is reported for unspecified
+// action in some versions.
+func normalizeAction(act string) string {
+	switch act {
+	case "NO ACTION", "RESTRICT":
+		return ""
+	}
+	return act
+}
+
+var intWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+// normalizeType converts column type into the form MySQL 8 reports: lower
+// case, without charset and display width of integer types
+func normalizeType(t string) string {
+	t = strings.ToLower(t)
+	if idx := strings.Index(t, " character set "); idx >= 0 {
+		t = t[:idx]
+	}
+	if idx := strings.Index(t, " collate "); idx >= 0 {
+		t = t[:idx]
+	}
+	return intWidth.ReplaceAllString(t, "$1")
+}
+
+// Tables implements driver.Inspector
+func (d *drv) Tables(db *sql.DB) (ret []string, err error) {
+	rows, err := db.Query(
+		`SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_TYPE='BASE TABLE' ORDER BY TABLE_NAME`,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return
+		}
+		ret = append(ret, s)
+	}
+
+	return ret, rows.Err()
+}
+
+// Columns implements driver.Inspector
+func (d *drv) Columns(db *sql.DB, table string) (ret []driver.ColumnInfo, err error) {
+	rows, err := db.Query(
+		`SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT FROM information_schema.COLUMNS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? ORDER BY ORDINAL_POSITION`,
+		table,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c        driver.ColumnInfo
+			nullable string
+			def      sql.NullString
+		)
+		if err = rows.Scan(&c.Name, &c.Type, &nullable, &def); err != nil {
+			return
+		}
+		c.Type = normalizeType(c.Type)
+		c.Nullable = nullable == "YES"
+		if def.Valid {
+			c.Default = &def.String
+		}
+		ret = append(ret, c)
+	}
+
+	return ret, rows.Err()
+}
+
+// Indexes implements driver.Inspector
+//
+// Indexes created implicitly by MySQL for foreign keys are reported as plain
+// index.
+func (d *drv) Indexes(db *sql.DB, table string) (ret []driver.Index, err error) {
+	if ret, err = d.indexes(db, table); err != nil {
+		return
+	}
+	return d.foreignKeys(db, table, ret)
+}
+
+func (d *drv) indexes(db *sql.DB, table string) (ret []driver.Index, err error) {
+	rows, err := db.Query(
+		`SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? ORDER BY INDEX_NAME='PRIMARY' DESC, INDEX_NAME, SEQ_IN_INDEX`,
+		table,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			name, col string
+			nonUnique int
+		)
+		if err = rows.Scan(&name, &nonUnique, &col); err != nil {
+			return
+		}
+
+		if l := len(ret); l > 0 && ret[l-1].Name == name {
+			ret[l-1].Cols = append(ret[l-1].Cols, col)
+			continue
+		}
+
+		typ := driver.IndexTypeIndex
+		switch {
+		case strings.EqualFold(name, "PRIMARY"):
+			typ = driver.IndexTypePrimary
+		case nonUnique == 0:
+			typ = driver.IndexTypeUnique
+		}
+		ret = append(ret, driver.Index{Type: typ, Name: name, Cols: []string{col}})
+	}
+
+	return ret, rows.Err()
+}
+
+func (d *drv) foreignKeys(db *sql.DB, table string, ret []driver.Index) ([]driver.Index, error) {
+	rows, err := db.Query(
+		`SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+  ON r.CONSTRAINT_SCHEMA=k.CONSTRAINT_SCHEMA AND r.TABLE_NAME=k.TABLE_NAME AND r.CONSTRAINT_NAME=k.CONSTRAINT_NAME
+WHERE k.TABLE_SCHEMA=DATABASE() AND k.TABLE_NAME=? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`,
+		table,
+	)
+	if err != nil {
+		return ret, err
+	}
+	defer rows.Close()
+
+	var cur *driver.Index
+	for rows.Next() {
+		var name, col, ref, refCol, onUpdate, onDelete string
+		if err = rows.Scan(&name, &col, &ref, &refCol, &onUpdate, &onDelete); err != nil {
+			return ret, err
+		}
+		if cur == nil || cur.Name != name {
+			if cur != nil {
+				ret = append(ret, *cur)
+			}
+			cur = &driver.Index{
+				Type: driver.IndexTypeForeign,
+				Name: name,
+				Ref: &driver.ForeignKey{
+					Table:    ref,
+					OnDelete: normalizeAction(onDelete),
+					OnUpdate: normalizeAction(onUpdate),
+				},
+			}
+		}
+		cur.Cols = append(cur.Cols, col)
+		cur.Ref.Cols = append(cur.Ref.Cols, refCol)
+	}
+	if cur != nil {
+		ret = append(ret, *cur)
+	}
+
+	return ret, rows.Err()
+}
+
+// Expect implements driver.Inspector
+//
+// MySQL creates a plain index for foreign key if no index starts with its
+// columns, which is also expected.
+func (d *drv) Expect(typ reflect.Type, cols []driver.Column, indexes []driver.Index) (retCols []driver.ColumnInfo, retIdx []driver.Index) {
+	hasPK := false
+	for _, i := range indexes {
+		if i.Type == driver.IndexTypePrimary {
+			hasPK = true
+			break
+		}
+	}
+
+	for _, c := range cols {
+		ft := typ.FieldByIndex(c.Index).Type
+		t := c.Type
+		if t == "" {
+			t = d.getType(ft, c.Name, c.Size, indexes)
+		}
+		retCols = append(retCols, driver.ColumnInfo{
+			Name:     c.Name,
+			Type:     normalizeType(t),
+			Nullable: !c.NotNull(ft),
+		})
+		if c.AI && !hasPK {
+			retIdx = append(retIdx, driver.Index{
+				Type: driver.IndexTypePrimary,
+				Name: "PRIMARY",
+				Cols: []string{c.Name},
+			})
+		}
+	}
+
+	for _, i := range indexes {
+		if i.Type == driver.IndexTypePrimary {
+			i.Name = "PRIMARY"
+		}
+		if i.Type == driver.IndexTypeForeign {
+			ref := *i.Ref
+			ref.OnDelete = normalizeAction(ref.OnDelete)
+			ref.OnUpdate = normalizeAction(ref.OnUpdate)
+			i.Ref = &ref
+		}
+		retIdx = append(retIdx, i)
+	}
+
+	for _, i := range indexes {
+		if i.Type != driver.IndexTypeForeign || hasPrefixIndex(retIdx, i.Cols) {
+			continue
+		}
+		retIdx = append(retIdx, driver.Index{
+			Type: driver.IndexTypeIndex,
+			Name: i.Name,
+			Cols: i.Cols,
+		})
+	}
+
+	return
+}
+
+// hasPrefixIndex determins if there's an index (not foreign key) starts with
+// cols
+func hasPrefixIndex(indexes []driver.Index, cols []string) bool {
+	for _, i := range indexes {
+		if i.Type == driver.IndexTypeForeign || len(i.Cols) < len(cols) {
+			continue
+		}
+		match := true
+		for k, c := range cols {
+			if i.Cols[k] != c {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"database/sql"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func sortIndexes(idx []driver.Index) {
+	sort.Slice(idx, func(a, b int) bool {
+		if idx[a].Type != idx[b].Type {
+			return idx[a].Type < idx[b].Type
+		}
+		return idx[a].Name < idx[b].Name
+	})
+}
+
+// TestInspector creates tables in live MySQL, and checks if what Inspector
+// reports is same as what Expect says
+func TestInspector(t *testing.T) {
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		t.Fatal("You must provide MYSQL_DSN environment variable to run test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("cannot open db: %s", err)
+	}
+	defer db.Close()
+
+	type grp struct {
+		ID   int    `sdm:"id"`
+		Name string `sdm:"name"`
+	}
+	type member struct {
+		GID  int     `sdm:"gid"`
+		Name string  `sdm:"name"`
+		Memo *string `sdm:"memo"`
+	}
+	tables := []struct {
+		name string
+		typ  reflect.Type
+		cols []driver.Column
+		idx  []driver.Index
+	}{
+		{
+			name: "sdm_inspect_grp",
+			typ:  reflect.TypeOf(grp{}),
+			cols: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, Name: "name", Size: 32},
+			},
+		},
+		{
+			name: "sdm_inspect_member",
+			typ:  reflect.TypeOf(member{}),
+			cols: []driver.Column{
+				{Index: []int{0}, Name: "gid"},
+				{Index: []int{1}, Name: "name", Size: 32},
+				{Index: []int{2}, Name: "memo", Size: 64},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypePrimary, Name: "member_pk", Cols: []string{"gid", "name"}},
+				{Type: driver.IndexTypeUnique, Name: "memo", Cols: []string{"memo", "name"}},
+				{Type: driver.IndexTypeForeign, Name: "member_grp", Cols: []string{"gid"}, Ref: &driver.ForeignKey{
+					Table:    "sdm_inspect_grp",
+					Cols:     []string{"id"},
+					OnDelete: "CASCADE",
+				}},
+			},
+		},
+	}
+
+	d := driver.GetDriver("mysql")
+	i, ok := d.(driver.Inspector)
+	if !ok {
+		t.Fatal("expected mysql driver implements driver.Inspector")
+	}
+
+	drop := func() {
+		for x := len(tables) - 1; x >= 0; x-- {
+			db.Exec("DROP TABLE IF EXISTS " + quote(tables[x].name))
+		}
+	}
+	drop()
+	defer drop()
+	for _, tbl := range tables {
+		if _, err := d.CreateTable(db, tbl.name, tbl.typ, tbl.cols, tbl.idx); err != nil {
+			t.Fatalf("cannot create table %s: %s", tbl.name, err)
+		}
+	}
+
+	names, err := i.Tables(db)
+	if err != nil {
+		t.Fatalf("cannot list tables: %s", err)
+	}
+	found := 0
+	for _, n := range names {
+		if n == tables[0].name || n == tables[1].name {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("expected created tables are listed, got %v", names)
+	}
+
+	for _, tbl := range tables {
+		expectCols, expectIdx := i.Expect(tbl.typ, tbl.cols, tbl.idx)
+
+		cols, err := i.Columns(db, tbl.name)
+		if err != nil {
+			t.Fatalf("cannot list columns of %s: %s", tbl.name, err)
+		}
+		for x := range cols {
+			cols[x].Default = nil
+		}
+		if !reflect.DeepEqual(cols, expectCols) {
+			t.Errorf("%s: expected columns %+v, got %+v", tbl.name, expectCols, cols)
+		}
+
+		idx, err := i.Indexes(db, tbl.name)
+		if err != nil {
+			t.Fatalf("cannot list indexes of %s: %s", tbl.name, err)
+		}
+		sortIndexes(idx)
+		sortIndexes(expectIdx)
+		if !reflect.DeepEqual(idx, expectIdx) {
+			t.Errorf("%s: expected indexes %+v, got %+v", tbl.name, expectIdx, idx)
+		}
+	}
+}
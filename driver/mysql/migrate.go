@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"reflect"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+func alter(table, spec string) []string {
+	return []string{"ALTER TABLE " + quote(table) + " " + spec}
+}
+
+// AddColumn implements driver.Migrator
+func (d *drv) AddColumn(table string, typ reflect.Type, col driver.Column, indexes []driver.Index) ([]string, bool) {
+	return alter(table, "ADD COLUMN "+d.fullColumnDef(typ, col, indexes)), true
+}
+
+// DropColumn implements driver.Migrator
+func (d *drv) DropColumn(table, col string) ([]string, bool) {
+	return alter(table, "DROP COLUMN "+quote(col)), true
+}
+
+// ModifyColumn implements driver.Migrator
+func (d *drv) ModifyColumn(table string, typ reflect.Type, col driver.Column, indexes []driver.Index) ([]string, bool) {
+	return alter(table, "MODIFY COLUMN "+d.fullColumnDef(typ, col, indexes)), true
+}
+
+// AddIndex implements driver.Migrator
+func (d *drv) AddIndex(table string, typ reflect.Type, cols []driver.Column, idx driver.Index) ([]string, bool) {
+	return alter(table, "ADD "+d.indexDef(typ, cols, idx)), true
+}
+
+// DropIndex implements driver.Migrator
+func (d *drv) DropIndex(table string, idx driver.Index) ([]string, bool) {
+	switch idx.Type {
+	case driver.IndexTypePrimary:
+		return alter(table, "DROP PRIMARY KEY"), true
+	case driver.IndexTypeForeign:
+		return alter(table, "DROP FOREIGN KEY "+quote(idx.Name)), true
+	}
+	return alter(table, "DROP INDEX "+quote(idx.Name)), true
+}
+
+// RenameTable implements driver.Migrator
+func (d *drv) RenameTable(from, to string) []string {
+	return []string{"RENAME TABLE " + quote(from) + " TO " + quote(to)}
+}
+
+// RenameColumn implements driver.Migrator
+//
+// It uses CHANGE COLUMN, which is supported before MySQL 8, so column
+// definition is also updated.
+func (d *drv) RenameColumn(table, from string, typ reflect.Type, col driver.Column, indexes []driver.Index) []string {
+	return alter(table, "CHANGE COLUMN "+quote(from)+" "+d.fullColumnDef(typ, col, indexes))
+}
@@ -0,0 +1,160 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+func TestMigrator(t *testing.T) {
+	d := &drv{
+		stringKeySize: "255",
+		blobKeySize:   "2048",
+		charset:       "utf8",
+		collate:       "utf8_general_ci",
+		Stub:          driver.Stub{QuoteFunc: quote},
+	}
+	typ := reflect.TypeOf(struct {
+		ID    int
+		Name  string
+		Owner int
+	}{})
+	cols := []driver.Column{
+		{Index: []int{0}, AI: true, Name: "id"},
+		{Index: []int{1}, Name: "name", Comment: "full name"},
+		{Index: []int{2}, Name: "owner"},
+	}
+	idx := []driver.Index{
+		{Type: driver.IndexTypeUnique, Name: "name", Cols: []string{"name"}},
+		{Type: driver.IndexTypeForeign, Name: "owner", Cols: []string{"owner"}, Ref: &driver.ForeignKey{
+			Table:    "user",
+			Cols:     []string{"id"},
+			OnDelete: "CASCADE",
+		}},
+	}
+
+	cases := []struct {
+		msg    string
+		stmts  []string
+		expect string
+	}{
+		{
+			msg:    "add column",
+			stmts:  must(d.AddColumn("t", typ, cols[1], idx)),
+			expect: "ALTER TABLE `t` ADD COLUMN `name` VARCHAR(255) CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL COMMENT 'full name'",
+		},
+		{
+			msg:    "modify column",
+			stmts:  must(d.ModifyColumn("t", typ, cols[0], idx)),
+			expect: "ALTER TABLE `t` MODIFY COLUMN `id` BIGINT NOT NULL AUTO_INCREMENT",
+		},
+		{
+			msg:    "drop column",
+			stmts:  must(d.DropColumn("t", "memo")),
+			expect: "ALTER TABLE `t` DROP COLUMN `memo`",
+		},
+		{
+			msg:    "add unique",
+			stmts:  must(d.AddIndex("t", typ, cols, idx[0])),
+			expect: "ALTER TABLE `t` ADD CONSTRAINT `name` UNIQUE KEY (`name`)",
+		},
+		{
+			msg:    "add foreign key",
+			stmts:  must(d.AddIndex("t", typ, cols, idx[1])),
+			expect: "ALTER TABLE `t` ADD CONSTRAINT `owner` FOREIGN KEY (`owner`) REFERENCES `user` (`id`) ON DELETE CASCADE",
+		},
+		{
+			msg:    "drop primary key",
+			stmts:  must(d.DropIndex("t", driver.Index{Type: driver.IndexTypePrimary, Name: "PRIMARY"})),
+			expect: "ALTER TABLE `t` DROP PRIMARY KEY",
+		},
+		{
+			msg:    "drop foreign key",
+			stmts:  must(d.DropIndex("t", idx[1])),
+			expect: "ALTER TABLE `t` DROP FOREIGN KEY `owner`",
+		},
+		{
+			msg:    "rename table",
+			stmts:  d.RenameTable("old", "t"),
+			expect: "RENAME TABLE `old` TO `t`",
+		},
+		{
+			msg:    "rename column",
+			stmts:  d.RenameColumn("t", "title", typ, cols[1], idx),
+			expect: "ALTER TABLE `t` CHANGE COLUMN `title` `name` VARCHAR(255) CHARACTER SET utf8 COLLATE utf8_general_ci NOT NULL COMMENT 'full name'",
+		},
+		{
+			msg:    "drop index",
+			stmts:  must(d.DropIndex("t", idx[0])),
+			expect: "ALTER TABLE `t` DROP INDEX `name`",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			if len(c.stmts) != 1 || c.stmts[0] != c.expect {
+				t.Errorf("dumping\nexpect: %s\nactual: %v", c.expect, c.stmts)
+			}
+		})
+	}
+}
+
+func must(stmts []string, ok bool) []string {
+	if !ok {
+		return nil
+	}
+	return stmts
+}
+
+func TestExpect(t *testing.T) {
+	d := &drv{
+		stringKeySize: "255",
+		charset:       "utf8",
+		collate:       "utf8_general_ci",
+		Stub:          driver.Stub{QuoteFunc: quote},
+	}
+	typ := reflect.TypeOf(struct {
+		ID    uint
+		Name  string
+		Owner *int
+	}{})
+	cols := []driver.Column{
+		{Index: []int{0}, AI: true, Name: "id"},
+		{Index: []int{1}, Name: "name", Type: "VARCHAR(32) CHARACTER SET utf8mb4"},
+		{Index: []int{2}, Name: "owner"},
+	}
+	idx := []driver.Index{
+		{Type: driver.IndexTypeForeign, Name: "owner", Cols: []string{"owner"}, Ref: &driver.ForeignKey{
+			Table:    "user",
+			Cols:     []string{"id"},
+			OnDelete: "RESTRICT",
+		}},
+	}
+
+	actualCols, actualIdx := d.Expect(typ, cols, idx)
+	expectCols := []driver.ColumnInfo{
+		{Name: "id", Type: "bigint unsigned"},
+		{Name: "name", Type: "varchar(32)"},
+		{Name: "owner", Type: "bigint", Nullable: true},
+	}
+	if !reflect.DeepEqual(actualCols, expectCols) {
+		t.Errorf("expected columns %+v, got %+v", expectCols, actualCols)
+	}
+
+	expectIdx := []driver.Index{
+		{Type: driver.IndexTypePrimary, Name: "PRIMARY", Cols: []string{"id"}},
+		{Type: driver.IndexTypeForeign, Name: "owner", Cols: []string{"owner"}, Ref: &driver.ForeignKey{
+			Table: "user",
+			Cols:  []string{"id"},
+		}},
+		{Type: driver.IndexTypeIndex, Name: "owner", Cols: []string{"owner"}},
+	}
+	if !reflect.DeepEqual(actualIdx, expectIdx) {
+		t.Errorf("expected indexes %+v, got %+v", expectIdx, actualIdx)
+	}
+
+	if typ := normalizeType("int(11) unsigned"); typ != "int unsigned" {
+		t.Errorf("expected display width is removed, got %s", typ)
+	}
+}
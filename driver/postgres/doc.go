@@ -0,0 +1,41 @@
+/*
+Package postgres implements PostgreSQL syntax generator.
+
+It generates SQL only, you have to import a database/sql driver like
+github.com/lib/pq or github.com/jackc/pgx/v5/stdlib yourself.
+
+This driver accepts no DSN parameter:
+
+  sdm.New(db, "postgres")
+
+Type mapping
+
+  - int8, int16, uint8: SMALLINT
+  - int32, uint16: INTEGER
+  - int, int64, uint, uint32, uint64: BIGINT
+  - float32, float64: REAL, DOUBLE PRECISION
+  - bool: BOOLEAN
+  - string: TEXT, or VARCHAR(n) if size is specified
+  - []byte: BYTEA
+  - time.Time: TIMESTAMPTZ
+
+PostgreSQL has no unsigned integer, uint64 values greater than max value of
+int64 cannot be stored.
+
+Auto increment
+
+Auto increment columns are created as identity columns (GENERATED BY DEFAULT AS
+IDENTITY). PostgreSQL does not support sql.Result.LastInsertId, so the driver
+implements driver.Returner, and SDM appends a RETURNING clause to INSERT
+statements to fill back the primary key.
+
+Placeholders
+
+PostgreSQL uses numbered placeholders like "$1", so the driver implements
+driver.Binder. Statements generated by SDM are numbered automatically, use
+sdm.Manager.Placeholders to build your own. In where clause passed to
+sdm.Manager.Update, number placeholders from 1 like "id=$1".
+
+Set sdm.Manager.Rebind to true if you prefer writing "?" in your SQL.
+*/
+package postgres
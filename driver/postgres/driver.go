@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func quoteString(str string) string {
+	return "'" + strings.Replace(str, "'", "''", -1) + "'"
+}
+
+func getType(typ reflect.Type, size int) string {
+	t := driver.ElementType(typ)
+
+	// check []byte first
+	switch typ.Kind() {
+	case reflect.Array, reflect.Slice:
+		if t.Kind() == reflect.Uint8 {
+			return "BYTEA"
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.String:
+		if size > 0 {
+			return "VARCHAR(" + strconv.Itoa(size) + ")"
+		}
+		return "TEXT"
+	default:
+		if driver.IsTime(t) {
+			return "TIMESTAMPTZ"
+		}
+	}
+
+	panic("sdm: driver: postgres: unsupported type " + t.String())
+}
+
+// columnDef generates column definition without constraints and comment
+func columnDef(typ reflect.Type, c driver.Column) string {
+	ft := typ.FieldByIndex(c.Index).Type
+	t := c.Type
+	if t == "" {
+		t = getType(ft, c.Size)
+	}
+
+	ret := quote(c.Name) + ` ` + t + c.Options(ft)
+	if c.AI {
+		ret += " GENERATED BY DEFAULT AS IDENTITY"
+	}
+	return ret
+}
+
+func indexDef(i driver.Index) (def string) {
+	quoted := make([]string, len(i.Cols))
+	for k, v := range i.Cols {
+		quoted[k] = quote(v)
+	}
+
+	switch i.Type {
+	case driver.IndexTypePrimary:
+		def = fmt.Sprintf(
+			"CONSTRAINT %s PRIMARY KEY (%s)",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+		)
+	case driver.IndexTypeUnique:
+		def = fmt.Sprintf(
+			"CONSTRAINT %s UNIQUE (%s)",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+		)
+	case driver.IndexTypeForeign:
+		def = fmt.Sprintf(
+			"CONSTRAINT %s FOREIGN KEY (%s) %s",
+			quote(i.Name),
+			strings.Join(quoted, ","),
+			i.Ref.Clause(quote),
+		)
+	}
+
+	return
+}
+
+func createTableColumnSQL(typ reflect.Type, cols []driver.Column, indexes []driver.Index) string {
+	ret := make([]string, 0, len(cols)+len(indexes)+1)
+
+	var aiIndex *driver.Index
+	for _, c := range cols {
+		if c.AI {
+			hasPK := false
+			for _, i := range indexes {
+				if i.Type == driver.IndexTypePrimary {
+					hasPK = true
+					break
+				}
+			}
+			if !hasPK {
+				aiIndex = &driver.Index{
+					Type: driver.IndexTypePrimary,
+					Name: typ.Name() + "_pk",
+					Cols: []string{c.Name},
+				}
+			}
+		}
+		ret = append(ret, columnDef(typ, c))
+	}
+
+	if aiIndex != nil {
+		ret = append(ret, indexDef(*aiIndex))
+	}
+	for _, i := range indexes {
+		if i.Type == driver.IndexTypeIndex {
+			// created by CREATE INDEX
+			continue
+		}
+		ret = append(ret, indexDef(i))
+	}
+
+	return strings.Join(ret, ",")
+}
+
+// createTableSQL generates CREATE TABLE statement, followed by CREATE INDEX
+// and COMMENT statements
+func createTableSQL(name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index, ifNotExist bool) []string {
+	cond := ""
+	if ifNotExist {
+		cond = "IF NOT EXISTS "
+	}
+
+	ret := []string{fmt.Sprintf(
+		"CREATE TABLE %s%s (%s)",
+		cond,
+		quote(name),
+		createTableColumnSQL(typ, cols, indexes),
+	)}
+
+	for _, i := range indexes {
+		if i.Type != driver.IndexTypeIndex {
+			continue
+		}
+		quoted := make([]string, len(i.Cols))
+		for k, v := range i.Cols {
+			quoted[k] = quote(v)
+		}
+		ret = append(ret, fmt.Sprintf(
+			"CREATE INDEX %s%s ON %s (%s)",
+			cond,
+			quote(i.Name),
+			quote(name),
+			strings.Join(quoted, ","),
+		))
+	}
+
+	for _, c := range cols {
+		if c.Comment == "" {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf(
+			"COMMENT ON COLUMN %s.%s IS %s",
+			quote(name),
+			quote(c.Name),
+			quoteString(c.Comment),
+		))
+	}
+
+	return ret
+}
+
+type drv struct {
+	driver.Stub
+}
+
+func (d drv) CreateTable(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
+	return db.Exec(strings.Join(createTableSQL(name, typ, cols, indexes, false), ";"))
+}
+
+func (d drv) CreateTableNotExist(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
+	return db.Exec(strings.Join(createTableSQL(name, typ, cols, indexes, true), ";"))
+}
+
+func (d drv) Col(table, col string, kind driver.QuotingType) string {
+	if kind == driver.QWhere {
+		return quote(table) + "." + quote(col)
+	}
+
+	return quote(col)
+}
+
+// Placeholder implements driver.Binder
+func (d drv) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Returning implements driver.Returner
+func (d drv) Returning(col string) string {
+	return " RETURNING " + quote(col)
+}
+
+// NormalizeTime implements driver.TimeNormalizer
+//
+// PostgreSQL stores time in microsecond precision.
+func (d drv) NormalizeTime(t time.Time) time.Time {
+	return t.Round(time.Microsecond)
+}
+
+// Syntax implements driver.SyntaxProvider
+//
+// Backslash is literal unless in escape string like E'\n', which is the default
+// since PostgreSQL 9.1 (standard_conforming_strings = on).
+func (d drv) Syntax() driver.Syntax {
+	return driver.Syntax{DollarQuote: true}
+}
+
+func init() {
+	driver.RegisterDriver("postgres", func(p map[string]string) driver.Driver {
+		return drv{
+			Stub: driver.Stub{QuoteFunc: quote},
+		}
+	})
+}
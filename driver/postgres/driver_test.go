@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+type testSQLCase struct {
+	t    reflect.Type
+	col  []driver.Column
+	idx  []driver.Index
+	qstr string
+	msg  string
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	cases := []testSQLCase{
+		{
+			t: reflect.TypeOf(struct {
+				ID   int
+				T    time.Time
+				B    []byte
+				OK   bool
+				F    float64
+				Name *string
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, Name: "id"},
+				{Index: []int{1}, Name: "t"},
+				{Index: []int{2}, Name: "b"},
+				{Index: []int{3}, Name: "ok"},
+				{Index: []int{4}, Name: "f"},
+				{Index: []int{5}, Name: "name", Size: 32},
+			},
+			qstr: `CREATE TABLE "test" ("id" BIGINT NOT NULL,"t" TIMESTAMPTZ NOT NULL,"b" BYTEA,"ok" BOOLEAN NOT NULL,"f" DOUBLE PRECISION NOT NULL,"name" VARCHAR(32))`,
+			msg:  "type mapping",
+		},
+		{
+			t: reflect.TypeOf(struct {
+				ID   int
+				Name string
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, Name: "name", Comment: "user's name"},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypeIndex, Name: "test_name", Cols: []string{"name"}},
+			},
+			qstr: `CREATE TABLE "test" ("id" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,"name" TEXT NOT NULL,CONSTRAINT "_pk" PRIMARY KEY ("id"));` +
+				`CREATE INDEX "test_name" ON "test" ("name");` +
+				`COMMENT ON COLUMN "test"."name" IS 'user''s name'`,
+			msg: "identity column with index and comment",
+		},
+		{
+			t: reflect.TypeOf(struct {
+				ID    int
+				Year  int
+				Month int
+				Owner int
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, Name: "y"},
+				{Index: []int{2}, Name: "m"},
+				{Index: []int{3}, Name: "owner"},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypePrimary, Name: "test_pk", Cols: []string{"id"}},
+				{Type: driver.IndexTypeUnique, Name: "ym", Cols: []string{"y", "m"}},
+				{Type: driver.IndexTypeForeign, Name: "owner", Cols: []string{"owner"}, Ref: &driver.ForeignKey{
+					Table:    "user",
+					Cols:     []string{"id"},
+					OnDelete: "CASCADE",
+				}},
+			},
+			qstr: `CREATE TABLE "test" ("id" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY,"y" BIGINT NOT NULL,"m" BIGINT NOT NULL,"owner" BIGINT NOT NULL,` +
+				`CONSTRAINT "test_pk" PRIMARY KEY ("id"),CONSTRAINT "ym" UNIQUE ("y","m"),` +
+				`CONSTRAINT "owner" FOREIGN KEY ("owner") REFERENCES "user" ("id") ON DELETE CASCADE)`,
+			msg: "primary, unique and foreign key",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			actual := strings.Join(createTableSQL("test", c.t, c.col, c.idx, false), ";")
+			expect := c.qstr
+			if actual != expect {
+				t.Errorf("dumping\nexpect: %s\nactual: %s", expect, actual)
+			}
+		})
+	}
+}
+
+func TestCreateTableSQLNotExist(t *testing.T) {
+	typ := reflect.TypeOf(struct{ Name string }{})
+	actual := createTableSQL("test", typ, []driver.Column{
+		{Index: []int{0}, Name: "name"},
+	}, []driver.Index{
+		{Type: driver.IndexTypeIndex, Name: "test_name", Cols: []string{"name"}},
+	}, true)
+	expect := []string{
+		`CREATE TABLE IF NOT EXISTS "test" ("name" TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS "test_name" ON "test" ("name")`,
+	}
+	if !reflect.DeepEqual(expect, actual) {
+		t.Errorf("dumping\nexpect: %#v\nactual: %#v", expect, actual)
+	}
+}
+
+func TestDriver(t *testing.T) {
+	d, ok := driver.GetDriver("postgres").(drv)
+	if !ok {
+		t.Fatal("postgres driver is not registered")
+	}
+
+	if actual := d.Quote(`a"b`); actual != `"a""b"` {
+		t.Errorf("unexpected quoted identifier: %s", actual)
+	}
+	if actual := d.Col("t", "c", driver.QWhere); actual != `"t"."c"` {
+		t.Errorf("unexpected column in WHERE: %s", actual)
+	}
+	if actual := d.Col("t", "c", driver.QSelect); actual != `"c"` {
+		t.Errorf("unexpected column in SELECT: %s", actual)
+	}
+	if actual := d.Placeholder(12); actual != "$12" {
+		t.Errorf("unexpected placeholder: %s", actual)
+	}
+	if actual := d.Returning("id"); actual != ` RETURNING "id"` {
+		t.Errorf("unexpected RETURNING clause: %s", actual)
+	}
+
+	var _ driver.Binder = d
+	var _ driver.Returner = d
+	var _ driver.TimeNormalizer = d
+}
@@ -5,7 +5,9 @@ import (
 	sqlDriver "database/sql/driver"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Ronmi/sdm/driver"
 )
@@ -20,7 +22,7 @@ const (
 // with time=string, store this format of time in db
 const TimeStringFormat = "2006-01-02T15:04:05-0700"
 
-func getType(typ reflect.Type, timeAs string) string {
+func getType(typ reflect.Type, timeAs string, size int) string {
 	t := driver.ElementType(typ)
 
 	// check []byte first
@@ -31,22 +33,20 @@ func getType(typ reflect.Type, timeAs string) string {
 		}
 	}
 
-	postfix := ""
-	if typ.Kind() != reflect.Ptr {
-		postfix = " NOT NULL"
-	}
-
 	switch t.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		fallthrough
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		fallthrough
 	case reflect.Bool:
-		return "INTEGER" + postfix
+		return "INTEGER"
 	case reflect.Float32, reflect.Float64:
-		return "REAL" + postfix
+		return "REAL"
 	case reflect.String:
-		return "TEXT" + postfix
+		if size > 0 {
+			return "VARCHAR(" + strconv.Itoa(size) + ")"
+		}
+		return "TEXT"
 	default:
 		if driver.IsTime(t) {
 			ret := "DATETIME"
@@ -56,13 +56,25 @@ func getType(typ reflect.Type, timeAs string) string {
 			case TimeAsInt:
 				ret = "INTEGER"
 			}
-			return ret + postfix
+			return ret
 		}
 	}
 
 	panic("sdm: driver: sqlite3: unsupported type " + t.String())
 }
 
+// columnDef generates column definition without constraints, comment is
+// ignored since sqlite does not support it
+func columnDef(typ reflect.Type, c driver.Column, timeAs string) string {
+	ft := typ.FieldByIndex(c.Index).Type
+	t := c.Type
+	if t == "" {
+		t = getType(ft, timeAs, c.Size)
+	}
+
+	return quote(c.Name) + ` ` + t + c.Options(ft)
+}
+
 func quote(name string) string {
 	return `"` + strings.Replace(name, `"`, "\\\"", -1) + `"`
 }
@@ -73,7 +85,7 @@ func createTableColumnSQL(typ reflect.Type, cols []driver.Column, indexes []driv
 	hasAI := false
 
 	for _, c := range cols {
-		def := quote(c.Name) + ` ` + getType(typ.Field(c.ID).Type, timeAs)
+		def := columnDef(typ, c, timeAs)
 		if c.AI {
 			hasAI = true
 			// in sqlite, auto increment must pair with primary key
@@ -114,6 +126,13 @@ func createTableColumnSQL(typ reflect.Type, cols []driver.Column, indexes []driv
 				quote(i.Name),
 				strings.Join(quoted, ","),
 			)
+		case driver.IndexTypeForeign:
+			def = fmt.Sprintf(
+				"CONSTRAINT %s FOREIGN KEY (%s) %s",
+				quote(i.Name),
+				strings.Join(quoted, ","),
+				i.Ref.Clause(quote),
+			)
 		}
 
 		ret = append(ret, def)
@@ -127,14 +146,17 @@ type drv struct {
 	driver.Stub
 }
 
-func (d drv) CreateTable(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
-	qstr := fmt.Sprintf(
+// CreateTableSQL implements driver.Migrator
+func (d drv) CreateTableSQL(name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) string {
+	return fmt.Sprintf(
 		"CREATE TABLE '%s' (%s)",
 		name,
 		createTableColumnSQL(typ, cols, indexes, d.timeAs),
 	)
+}
 
-	return db.Exec(qstr)
+func (d drv) CreateTable(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
+	return db.Exec(d.CreateTableSQL(name, typ, cols, indexes))
 }
 
 func (d drv) CreateTableNotExist(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, indexes []driver.Index) (sql.Result, error) {
@@ -195,6 +217,28 @@ func (d drv) getWrapper(v reflect.Value) (ret wrapper, ok bool) {
 	return wrapTimeInt{v: v, nullable: k == reflect.Ptr}, true
 }
 
+// Syntax implements driver.SyntaxProvider
+//
+// SQLite follows standard SQL: backslash is a normal character in literals.
+func (d drv) Syntax() driver.Syntax {
+	return driver.Syntax{}
+}
+
+// NormalizeTime implements driver.TimeNormalizer
+func (d drv) NormalizeTime(t time.Time) time.Time {
+	switch d.timeAs {
+	case TimeAsInt:
+		return time.Unix(t.Unix(), 0)
+	case TimeAsString:
+		if ret, err := time.Parse(TimeStringFormat, t.Format(TimeStringFormat)); err == nil {
+			return ret
+		}
+	}
+
+	// go-sqlite3 reads DATETIME in UTC
+	return t.Round(0).UTC()
+}
+
 func init() {
 	driver.RegisterDriver("sqlite3", func(p map[string]string) driver.Driver {
 		var timeAs = TimeAsTime
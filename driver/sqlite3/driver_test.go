@@ -18,6 +18,7 @@ type testSQLCase struct {
 }
 
 func TestColumnSQL(t *testing.T) {
+	yes, no := true, false
 	cases := []testSQLCase{
 		{
 			timeAs: TimeAsInt,
@@ -26,8 +27,8 @@ func TestColumnSQL(t *testing.T) {
 				T  time.Time
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "t"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "t"},
 			},
 			idx:  []driver.Index{},
 			qstr: `"id" INTEGER NOT NULL,"t" INTEGER NOT NULL`,
@@ -40,8 +41,8 @@ func TestColumnSQL(t *testing.T) {
 				T  time.Time
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "t"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "t"},
 			},
 			idx:  []driver.Index{},
 			qstr: `"id" INTEGER NOT NULL,"t" TEXT NOT NULL`,
@@ -57,11 +58,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypePrimary, Name: "test_pk", Cols: []string{"id"}},
@@ -80,11 +81,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypePrimary, Name: "test_pk", Cols: []string{"id"}},
@@ -103,11 +104,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypeUnique, Name: "birth", Cols: []string{"y", "m", "d"}},
@@ -126,12 +127,12 @@ func TestColumnSQL(t *testing.T) {
 				Col5 []byte    `driver:"c5"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: false, Name: "id"},
-				{ID: 1, AI: false, Name: "c1"},
-				{ID: 2, AI: false, Name: "c2"},
-				{ID: 3, AI: false, Name: "c3"},
-				{ID: 4, AI: false, Name: "c4"},
-				{ID: 5, AI: false, Name: "c5"},
+				{Index: []int{0}, AI: false, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "c1"},
+				{Index: []int{2}, AI: false, Name: "c2"},
+				{Index: []int{3}, AI: false, Name: "c3"},
+				{Index: []int{4}, AI: false, Name: "c4"},
+				{Index: []int{5}, AI: false, Name: "c5"},
 			},
 			idx:  []driver.Index{},
 			qstr: `"id" INTEGER NOT NULL,"c1" REAL NOT NULL,"c2" INTEGER NOT NULL,"c3" DATETIME NOT NULL,"c4" TEXT NOT NULL,"c5" BLOB`,
@@ -147,11 +148,11 @@ func TestColumnSQL(t *testing.T) {
 				Name  string `driver:"name"`
 			}{}),
 			col: []driver.Column{
-				{ID: 0, AI: true, Name: "id"},
-				{ID: 1, AI: false, Name: "y"},
-				{ID: 2, AI: false, Name: "m"},
-				{ID: 3, AI: false, Name: "d"},
-				{ID: 4, AI: false, Name: "name"},
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "y"},
+				{Index: []int{2}, AI: false, Name: "m"},
+				{Index: []int{3}, AI: false, Name: "d"},
+				{Index: []int{4}, AI: false, Name: "name"},
 			},
 			idx: []driver.Index{
 				{Type: driver.IndexTypeIndex, Name: "birth", Cols: []string{"y", "m", "d"}},
@@ -159,6 +160,64 @@ func TestColumnSQL(t *testing.T) {
 			qstr: `"id" INTEGER NOT NULL CONSTRAINT "_pk" PRIMARY KEY AUTOINCREMENT,"y" INTEGER NOT NULL,"m" INTEGER NOT NULL,"d" INTEGER NOT NULL,"name" TEXT NOT NULL`,
 			msg:  "well-defined struct with auto increment and index key",
 		},
+		{
+			timeAs: TimeAsInt,
+			t: reflect.TypeOf(struct {
+				ID   int `driver:"id,ai"`
+				Addr struct {
+					Street string    `driver:"street"`
+					Since  time.Time `driver:"since"`
+				} `driver:"addr_,inline"`
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1, 0}, AI: false, Name: "addr_street"},
+				{Index: []int{1, 1}, AI: false, Name: "addr_since"},
+			},
+			idx:  []driver.Index{},
+			qstr: `"id" INTEGER NOT NULL CONSTRAINT "_pk" PRIMARY KEY AUTOINCREMENT,"addr_street" TEXT NOT NULL,"addr_since" INTEGER NOT NULL`,
+			msg:  "nested struct fields",
+		},
+		{
+			timeAs: TimeAsInt,
+			t: reflect.TypeOf(struct {
+				ID    int
+				Name  string
+				Price float64
+				Memo  *string
+				Data  []byte
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: false, Name: "id", Default: "0", Comment: "ignored"},
+				{Index: []int{1}, AI: false, Name: "name", Size: 64, Default: "''"},
+				{Index: []int{2}, AI: false, Name: "price", Type: "NUMERIC(10,2)", Nullable: &yes},
+				{Index: []int{3}, AI: false, Name: "memo", Nullable: &no},
+				{Index: []int{4}, AI: false, Name: "data", Nullable: &no},
+			},
+			idx:  []driver.Index{},
+			qstr: `"id" INTEGER NOT NULL DEFAULT 0,"name" VARCHAR(64) NOT NULL DEFAULT '',"price" NUMERIC(10,2) NULL,"memo" TEXT NOT NULL,"data" BLOB NOT NULL`,
+			msg:  "column options",
+		},
+		{
+			timeAs: TimeAsInt,
+			t: reflect.TypeOf(struct {
+				ID    int
+				Owner int
+			}{}),
+			col: []driver.Column{
+				{Index: []int{0}, AI: true, Name: "id"},
+				{Index: []int{1}, AI: false, Name: "owner"},
+			},
+			idx: []driver.Index{
+				{Type: driver.IndexTypeForeign, Name: "owner", Cols: []string{"owner"}, Ref: &driver.ForeignKey{
+					Table:    "user",
+					Cols:     []string{"id"},
+					OnDelete: "CASCADE",
+				}},
+			},
+			qstr: `"id" INTEGER NOT NULL CONSTRAINT "_pk" PRIMARY KEY AUTOINCREMENT,"owner" INTEGER NOT NULL,CONSTRAINT "owner" FOREIGN KEY ("owner") REFERENCES "user" ("id") ON DELETE CASCADE`,
+			msg:  "foreign key",
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,232 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// queryStrings runs a query returning single string column
+func queryStrings(db *sql.DB, qstr string, args ...interface{}) (ret []string, err error) {
+	rows, err := db.Query(qstr, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return
+		}
+		ret = append(ret, s)
+	}
+
+	return ret, rows.Err()
+}
+
+// normalizeAction converts referential action reported by sqlite
+func normalizeAction(act string) string {
+	if act == "NO ACTION" {
+		return ""
+	}
+	return act
+}
+
+// Tables implements driver.Inspector
+func (d drv) Tables(db *sql.DB) ([]string, error) {
+	return queryStrings(
+		db,
+		`SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name`,
+	)
+}
+
+// Columns implements driver.Inspector
+func (d drv) Columns(db *sql.DB, table string) (ret []driver.ColumnInfo, err error) {
+	rows, err := db.Query(
+		`SELECT name, type, "notnull", dflt_value FROM pragma_table_info(?) ORDER BY cid`,
+		table,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c       driver.ColumnInfo
+			notNull bool
+			def     sql.NullString
+		)
+		if err = rows.Scan(&c.Name, &c.Type, &notNull, &def); err != nil {
+			return
+		}
+		c.Nullable = !notNull
+		if def.Valid {
+			c.Default = &def.String
+		}
+		ret = append(ret, c)
+	}
+
+	return ret, rows.Err()
+}
+
+// Indexes implements driver.Inspector
+//
+// sqlite does not keep name of primary key and foreign key, they are reported
+// with empty name.
+func (d drv) Indexes(db *sql.DB, table string) (ret []driver.Index, err error) {
+	pk, err := queryStrings(
+		db,
+		`SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`,
+		table,
+	)
+	if err != nil {
+		return
+	}
+	if len(pk) > 0 {
+		ret = append(ret, driver.Index{Type: driver.IndexTypePrimary, Cols: pk})
+	}
+
+	if ret, err = d.indexes(db, table, ret); err != nil {
+		return
+	}
+	return d.foreignKeys(db, table, ret)
+}
+
+func (d drv) indexes(db *sql.DB, table string, ret []driver.Index) ([]driver.Index, error) {
+	rows, err := db.Query(
+		`SELECT name, "unique" FROM pragma_index_list(?) WHERE origin <> 'pk' ORDER BY name`,
+		table,
+	)
+	if err != nil {
+		return ret, err
+	}
+	// read all before querying columns, in case of single connection pool
+	idxes := []driver.Index{}
+	for rows.Next() {
+		var (
+			i      driver.Index
+			unique bool
+		)
+		if err = rows.Scan(&i.Name, &unique); err != nil {
+			rows.Close()
+			return ret, err
+		}
+		i.Type = driver.IndexTypeIndex
+		if unique {
+			i.Type = driver.IndexTypeUnique
+		}
+		idxes = append(idxes, i)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return ret, err
+	}
+
+	for _, i := range idxes {
+		i.Cols, err = queryStrings(
+			db,
+			`SELECT name FROM pragma_index_info(?) ORDER BY seqno`,
+			i.Name,
+		)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, i)
+	}
+
+	return ret, nil
+}
+
+func (d drv) foreignKeys(db *sql.DB, table string, ret []driver.Index) ([]driver.Index, error) {
+	rows, err := db.Query(
+		`SELECT id, "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`,
+		table,
+	)
+	if err != nil {
+		return ret, err
+	}
+	defer rows.Close()
+
+	var cur *driver.Index
+	lastID := -1
+	for rows.Next() {
+		var (
+			id                 int
+			ref, from          string
+			to                 sql.NullString
+			onUpdate, onDelete string
+		)
+		if err = rows.Scan(&id, &ref, &from, &to, &onUpdate, &onDelete); err != nil {
+			return ret, err
+		}
+		if id != lastID {
+			if cur != nil {
+				ret = append(ret, *cur)
+			}
+			cur = &driver.Index{
+				Type: driver.IndexTypeForeign,
+				Ref: &driver.ForeignKey{
+					Table:    ref,
+					OnDelete: normalizeAction(onDelete),
+					OnUpdate: normalizeAction(onUpdate),
+				},
+			}
+			lastID = id
+		}
+		cur.Cols = append(cur.Cols, from)
+		// "to" is NULL if primary key of referenced table is used implicitly
+		cur.Ref.Cols = append(cur.Ref.Cols, to.String)
+	}
+	if cur != nil {
+		ret = append(ret, *cur)
+	}
+
+	return ret, rows.Err()
+}
+
+// Expect implements driver.Inspector
+//
+// Plain indexes are not created by this driver, so they are not expected.
+func (d drv) Expect(typ reflect.Type, cols []driver.Column, indexes []driver.Index) (retCols []driver.ColumnInfo, retIdx []driver.Index) {
+	var ai string
+	for _, c := range cols {
+		ft := typ.FieldByIndex(c.Index).Type
+		t := c.Type
+		if t == "" {
+			t = getType(ft, d.timeAs, c.Size)
+		}
+		retCols = append(retCols, driver.ColumnInfo{
+			Name:     c.Name,
+			Type:     t,
+			Nullable: !c.NotNull(ft),
+		})
+		if c.AI {
+			ai = c.Name
+		}
+	}
+
+	if ai != "" {
+		retIdx = append(retIdx, driver.Index{Type: driver.IndexTypePrimary, Cols: []string{ai}})
+	}
+	for _, i := range indexes {
+		switch i.Type {
+		case driver.IndexTypeIndex:
+			continue
+		case driver.IndexTypePrimary:
+			if ai != "" {
+				continue
+			}
+		case driver.IndexTypeForeign:
+			ref := *i.Ref
+			ref.OnDelete = normalizeAction(ref.OnDelete)
+			ref.OnUpdate = normalizeAction(ref.OnUpdate)
+			i.Ref = &ref
+		}
+		retIdx = append(retIdx, i)
+	}
+
+	return
+}
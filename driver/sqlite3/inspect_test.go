@@ -0,0 +1,83 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestInspector(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("cannot open db: %s", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	schema := []string{
+		`CREATE TABLE "grp" ("id" INTEGER NOT NULL CONSTRAINT "grp_pk" PRIMARY KEY AUTOINCREMENT,"name" TEXT NOT NULL DEFAULT '')`,
+		`CREATE TABLE "member" ("gid" INTEGER NOT NULL,"name" VARCHAR(32) NOT NULL,"memo" TEXT,` +
+			`CONSTRAINT "member_pk" PRIMARY KEY ("gid","name"),` +
+			`CONSTRAINT "grp" FOREIGN KEY ("gid") REFERENCES "grp" ("id") ON DELETE CASCADE)`,
+		`CREATE UNIQUE INDEX "memo" ON "member" ("memo","name")`,
+	}
+	for _, s := range schema {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("cannot create schema: %s", err)
+		}
+	}
+
+	var i driver.Inspector = drv{timeAs: TimeAsTime}
+
+	tables, err := i.Tables(db)
+	if err != nil {
+		t.Fatalf("cannot list tables: %s", err)
+	}
+	if e := []string{"grp", "member"}; !reflect.DeepEqual(tables, e) {
+		t.Errorf("expected tables %v, got %v", e, tables)
+	}
+
+	cols, err := i.Columns(db, "grp")
+	if err != nil {
+		t.Fatalf("cannot list columns: %s", err)
+	}
+	empty := "''"
+	expectCols := []driver.ColumnInfo{
+		{Name: "id", Type: "INTEGER", Nullable: false},
+		{Name: "name", Type: "TEXT", Nullable: false, Default: &empty},
+	}
+	if !reflect.DeepEqual(cols, expectCols) {
+		t.Errorf("expected columns %+v, got %+v", expectCols, cols)
+	}
+
+	idxes, err := i.Indexes(db, "grp")
+	if err != nil {
+		t.Fatalf("cannot list indexes of grp: %s", err)
+	}
+	expectIdx := []driver.Index{
+		{Type: driver.IndexTypePrimary, Cols: []string{"id"}},
+	}
+	if !reflect.DeepEqual(idxes, expectIdx) {
+		t.Errorf("expected indexes of grp %+v, got %+v", expectIdx, idxes)
+	}
+
+	idxes, err = i.Indexes(db, "member")
+	if err != nil {
+		t.Fatalf("cannot list indexes of member: %s", err)
+	}
+	expectIdx = []driver.Index{
+		{Type: driver.IndexTypePrimary, Cols: []string{"gid", "name"}},
+		{Type: driver.IndexTypeUnique, Name: "memo", Cols: []string{"memo", "name"}},
+		{Type: driver.IndexTypeForeign, Cols: []string{"gid"}, Ref: &driver.ForeignKey{
+			Table:    "grp",
+			Cols:     []string{"id"},
+			OnDelete: "CASCADE",
+		}},
+	}
+	if !reflect.DeepEqual(idxes, expectIdx) {
+		t.Errorf("expected indexes of member %+v, got %+v", expectIdx, idxes)
+	}
+}
@@ -0,0 +1,97 @@
+package sqlite3
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// AddColumn implements driver.Migrator
+//
+// sqlite cannot add auto increment column, or NOT NULL column without
+// default value.
+func (d drv) AddColumn(table string, typ reflect.Type, col driver.Column, indexes []driver.Index) ([]string, bool) {
+	if col.AI || (col.NotNull(typ.FieldByIndex(col.Index).Type) && col.Default == "") {
+		return nil, false
+	}
+
+	return []string{
+		"ALTER TABLE " + quote(table) + " ADD COLUMN " + columnDef(typ, col, d.timeAs),
+	}, true
+}
+
+// DropColumn implements driver.Migrator, it is not supported by sqlite
+func (d drv) DropColumn(table, col string) ([]string, bool) {
+	return nil, false
+}
+
+// ModifyColumn implements driver.Migrator, it is not supported by sqlite
+func (d drv) ModifyColumn(table string, typ reflect.Type, col driver.Column, indexes []driver.Index) ([]string, bool) {
+	return nil, false
+}
+
+// AddIndex implements driver.Migrator, only unique index is supported
+func (d drv) AddIndex(table string, typ reflect.Type, cols []driver.Column, idx driver.Index) ([]string, bool) {
+	if idx.Type != driver.IndexTypeUnique {
+		return nil, false
+	}
+
+	quoted := make([]string, len(idx.Cols))
+	for k, v := range idx.Cols {
+		quoted[k] = quote(v)
+	}
+	return []string{
+		"CREATE UNIQUE INDEX " + quote(idx.Name) + " ON " + quote(table) +
+			" (" + strings.Join(quoted, ",") + ")",
+	}, true
+}
+
+// DropIndex implements driver.Migrator
+//
+// Only indexes created by CREATE INDEX can be dropped, constraints are dropped
+// by rebuilding the table.
+func (d drv) DropIndex(table string, idx driver.Index) ([]string, bool) {
+	switch {
+	case idx.Type != driver.IndexTypeIndex && idx.Type != driver.IndexTypeUnique:
+		return nil, false
+	case idx.Name == "" || strings.HasPrefix(idx.Name, "sqlite_autoindex_"):
+		return nil, false
+	}
+
+	return []string{"DROP INDEX " + quote(idx.Name)}, true
+}
+
+// RebuildTable implements driver.Rebuilder
+//
+// Foreign key constraints should be disabled before running the statements,
+// or rows referencing the table might be affected.
+func (d drv) RebuildTable(table string, typ reflect.Type, cols []driver.Column, indexes []driver.Index, keep []string) []string {
+	tmp := "_sdm_" + table
+	ret := []string{d.CreateTableSQL(tmp, typ, cols, indexes)}
+	if len(keep) > 0 {
+		quoted := make([]string, len(keep))
+		for k, v := range keep {
+			quoted[k] = quote(v)
+		}
+		c := strings.Join(quoted, ",")
+		ret = append(ret, "INSERT INTO "+quote(tmp)+" ("+c+") SELECT "+c+" FROM "+quote(table))
+	}
+	return append(
+		ret,
+		"DROP TABLE "+quote(table),
+		"ALTER TABLE "+quote(tmp)+" RENAME TO "+quote(table),
+	)
+}
+
+// RenameTable implements driver.Migrator
+func (d drv) RenameTable(from, to string) []string {
+	return []string{"ALTER TABLE " + quote(from) + " RENAME TO " + quote(to)}
+}
+
+// RenameColumn implements driver.Migrator, only column name is changed
+func (d drv) RenameColumn(table, from string, typ reflect.Type, col driver.Column, indexes []driver.Index) []string {
+	return []string{
+		"ALTER TABLE " + quote(table) + " RENAME COLUMN " + quote(from) + " TO " + quote(col.Name),
+	}
+}
@@ -20,11 +20,18 @@ type wrapTimeString struct {
 }
 
 func (w wrapTimeString) Scan(src interface{}) error {
-	if reflect.TypeOf(src).Kind() != reflect.String {
+	var str string
+	switch x := src.(type) {
+	case nil:
+	case string:
+		str = x
+	case []byte:
+		// go-sqlite3 returns TEXT column as []byte
+		str = string(x)
+	default:
 		return errors.New("sdm: driver: sqlite3: this is not a string")
 	}
 
-	str := src.(string)
 	if str == "" {
 		if w.nullable {
 			w.v.Set(reflect.Zero(w.v.Type()))
@@ -35,7 +42,7 @@ func (w wrapTimeString) Scan(src interface{}) error {
 		return nil
 	}
 
-	t, err := time.Parse(TimeStringFormat, src.(string))
+	t, err := time.Parse(TimeStringFormat, str)
 	if err != nil {
 		return nil
 	}
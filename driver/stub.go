@@ -16,7 +16,8 @@ type wrappable interface {
 //
 // By providing a quote function, most features a driver should implement
 // are prepared for you. Only CreateTables and CreateblesNotExist are
-// not supported, and they always panic.
+// not supported, and they always panic. DropTable uses standard
+// "DROP TABLE" syntax.
 //
 // Using Stub, you should map nullable columns to fields declared as pointer type.
 // The only exception is string type, in which NULL is always mapped to "".
@@ -34,6 +35,11 @@ func (s Stub) CreateTableNotExist(db *sql.DB, name string, typ reflect.Type, col
 	panic("sdm: driver: Default stub driver does not support table creation!")
 }
 
+// DropTable drops table with standard SQL syntax.
+func (s Stub) DropTable(db *sql.DB, name string) (sql.Result, error) {
+	return db.Exec("DROP TABLE " + s.QuoteFunc(name))
+}
+
 func (s Stub) ParseColumnName(c string) string {
 	arr := strings.Split(c, ".")
 	return arr[len(arr)-1]
@@ -0,0 +1,118 @@
+package driver
+
+import "strings"
+
+// Syntax describes lexical rules of a SQL dialect, which is used to find out
+// string literals, quoted identifiers and comments in hand-written SQL.
+type Syntax struct {
+	// Backslash escapes next character in '...' and "..." (MySQL)
+	BackslashEscape bool
+
+	// $$...$$ and $tag$...$tag$ are string constants (PostgreSQL)
+	DollarQuote bool
+}
+
+// DefaultSyntax is used for drivers not implementing SyntaxProvider, which is
+// compatible with MySQL.
+var DefaultSyntax = Syntax{BackslashEscape: true}
+
+// SyntaxProvider is an optional interface for drivers with lexical rules other
+// than DefaultSyntax.
+type SyntaxProvider interface {
+	Syntax() Syntax
+}
+
+// SyntaxOf returns lexical rules of the driver
+func SyntaxOf(d Driver) Syntax {
+	if p, ok := d.(SyntaxProvider); ok {
+		return p.Syntax()
+	}
+	return DefaultSyntax
+}
+
+// IsIdentByte determins if c can be part of an unquoted identifier or keyword
+func IsIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 ||
+		c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9'
+}
+
+// Scan calls f with offset of each byte in qstr out of string literals, quoted
+// identifiers and comments. f returns number of bytes it consumes, the scanner
+// moves to next byte if it is less than 1.
+func (s Syntax) Scan(qstr string, f func(x int) (n int)) {
+	for x := 0; x < len(qstr); {
+		if n := s.skip(qstr, x); n > 0 {
+			x += n
+			continue
+		}
+		n := f(x)
+		if n < 1 {
+			n = 1
+		}
+		x += n
+	}
+}
+
+// skip returns length of string literal, quoted identifier or comment starting
+// at x, or 0 if there is none.
+func (s Syntax) skip(qstr string, x int) int {
+	rest := qstr[x:]
+	switch c := rest[0]; {
+	case c == '\'' || c == '"' || c == '`':
+		esc := c != '`' && (s.BackslashEscape || c == '\'' && s.DollarQuote && isEscapeString(qstr, x))
+		for y := 1; y < len(rest); y++ {
+			switch {
+			case esc && rest[y] == '\\':
+				y++
+			case rest[y] == c:
+				return y + 1
+			}
+		}
+		return len(rest)
+	case strings.HasPrefix(rest, "--"):
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return end
+		}
+		return len(rest)
+	case strings.HasPrefix(rest, "/*"):
+		if end := strings.Index(rest[2:], "*/"); end >= 0 {
+			return end + 4
+		}
+		return len(rest)
+	case c == '$' && s.DollarQuote && (x == 0 || !IsIdentByte(qstr[x-1])):
+		tag := dollarTag(rest)
+		if tag == "" {
+			return 0
+		}
+		if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+			return end + 2*len(tag)
+		}
+		return len(rest)
+	}
+
+	return 0
+}
+
+// isEscapeString determins if the quote at x starts an escape string constant
+// like E'\n' (PostgreSQL)
+func isEscapeString(qstr string, x int) bool {
+	if x < 1 || qstr[x-1] != 'E' && qstr[x-1] != 'e' {
+		return false
+	}
+	return x < 2 || !IsIdentByte(qstr[x-2])
+}
+
+// dollarTag returns the tag like "$$" or "$body$" at beginning of str, or
+// empty string if str does not start with a tag
+func dollarTag(str string) string {
+	y := 1
+	for y < len(str) && IsIdentByte(str[y]) {
+		y++
+	}
+	if y >= len(str) || str[y] != '$' || y > 1 && str[1] >= '0' && str[1] <= '9' {
+		return ""
+	}
+	return str[:y+1]
+}
@@ -1,9 +1,15 @@
 package driver
 
+import (
+	"reflect"
+	"strings"
+)
+
 const (
 	IndexTypeIndex   = "idx"
 	IndexTypeUnique  = "uniq"
 	IndexTypePrimary = "pri"
+	IndexTypeForeign = "fk"
 )
 
 // Index represents defination of an index
@@ -11,6 +17,40 @@ type Index struct {
 	Type string
 	Name string
 	Cols []string
+	Ref  *ForeignKey // referenced columns, only for IndexTypeForeign
+}
+
+// ForeignKey represents referenced side of a foreign key
+type ForeignKey struct {
+	Table    string
+	Cols     []string // referenced columns, in same order of Index.Cols
+	OnDelete string   // referential action in SQL syntax like "CASCADE", empty if not specified
+	OnUpdate string
+}
+
+// Actions generates referential actions part of foreign key definition, with
+// a leading space.
+func (f *ForeignKey) Actions() (ret string) {
+	if f.OnDelete != "" {
+		ret += " ON DELETE " + f.OnDelete
+	}
+	if f.OnUpdate != "" {
+		ret += " ON UPDATE " + f.OnUpdate
+	}
+	return
+}
+
+// Clause generates referenced side of foreign key definition, like
+//
+//     REFERENCES "table" ("col1","col2") ON DELETE CASCADE
+//
+// Identifiers are quoted with quote.
+func (f *ForeignKey) Clause(quote func(string) string) string {
+	refs := make([]string, len(f.Cols))
+	for k, v := range f.Cols {
+		refs[k] = quote(v)
+	}
+	return "REFERENCES " + quote(f.Table) + " (" + strings.Join(refs, ",") + ")" + f.Actions()
 }
 
 // HasCol determins is this index contains the column
@@ -26,7 +66,53 @@ func (i *Index) HasCol(name string) bool {
 
 // Column represents defination of a column, for internal use only
 type Column struct {
-	ID   int    // field id
-	AI   bool   // auto increment
-	Name string // column name
+	Index    []int  // index path of the field, see reflect.Type.FieldByIndex
+	AI       bool   // auto increment
+	Name     string // column name
+	Type     string // column type overriding the default one, empty if not specified
+	Size     int    // size of string or binary column, 0 if not specified
+	Default  string // default value in SQL syntax, empty if not specified
+	Nullable *bool  // nil if nullability depends on field type
+	Comment  string // column comment, ignored by drivers not supporting it
+	Was      string // previous column name, empty if not specified
+}
+
+// NotNull determins if column should be NOT NULL
+//
+// Pointer and byte slice are nullable unless specified.
+func (c Column) NotNull(typ reflect.Type) bool {
+	if c.Nullable != nil {
+		return !*c.Nullable
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return false
+	case reflect.Array, reflect.Slice:
+		return typ.Elem().Kind() != reflect.Uint8
+	}
+	return true
+}
+
+// Options generates nullability and default value part of column definition,
+// with a leading space.
+func (c Column) Options(typ reflect.Type) (ret string) {
+	if c.NotNull(typ) {
+		ret += " NOT NULL"
+	} else if c.Nullable != nil {
+		ret += " NULL"
+	}
+
+	if c.Default != "" {
+		ret += " DEFAULT " + c.Default
+	}
+	return
+}
+
+// ColumnInfo represents a column found in live database, see Inspector
+type ColumnInfo struct {
+	Name     string
+	Type     string // column type reported by database, like "varchar(64)"
+	Nullable bool
+	Default  *string // default value in SQL syntax, nil if not specified
 }
@@ -0,0 +1,138 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Possible reasons of ErrRegister
+var (
+	ErrNotStruct         = errors.New("sdm: not a struct type")
+	ErrEmptyColumn       = errors.New("sdm: empty column name")
+	ErrDuplicateColumn   = errors.New("sdm: duplicated column name")
+	ErrMultipleAI        = errors.New("sdm: more than one auto increment column")
+	ErrIndexConflict     = errors.New("sdm: index is declared with different types")
+	ErrMultipleVer       = errors.New("sdm: more than one version column")
+	ErrVerType           = errors.New("sdm: version column must be an integer")
+	ErrMultipleSoftDel   = errors.New("sdm: more than one soft delete column")
+	ErrSoftDelType       = errors.New("sdm: soft delete column must be *time.Time")
+	ErrMultipleTimestamp = errors.New("sdm: more than one created/updated column")
+	ErrTimestampType     = errors.New("sdm: created/updated column must be time.Time or *time.Time")
+	ErrInvalidOption     = errors.New("sdm: invalid column option")
+)
+
+// Errors of primary key based operations
+var (
+	ErrNoPrimaryKey    = errors.New("sdm: type does not have a primary key")
+	ErrNoAutoIncrement = errors.New("sdm: type does not have an auto increment column")
+	ErrNoColumn        = errors.New("sdm: no column to update")
+)
+
+// Errors of partial updates
+var (
+	ErrUnknownColumn = errors.New("sdm: unknown column")
+	ErrNotTracked    = errors.New("sdm: data is not tracked")
+)
+
+// ErrUnknownParam indicates a named parameter is not found in the map passed to
+// Manager.Named
+var ErrUnknownParam = errors.New("sdm: unknown parameter")
+
+// ErrInvalidClause indicates a malformed clause is passed to SelectBuilder
+var ErrInvalidClause = errors.New("sdm: invalid clause")
+
+// ErrInvalidDest indicates the destination passed to SelectBuilder.All is not
+// a pointer to slice of the type
+var ErrInvalidDest = errors.New("sdm: invalid destination")
+
+// ErrNoRowsAffected indicates a delete matches nothing, see Manager.StrictDelete
+var ErrNoRowsAffected = errors.New("sdm: no rows affected")
+
+// ErrStaleObject indicates an update of versioned data matches nothing, which
+// means the row is modified (or deleted) by others after it is loaded.
+var ErrStaleObject = errors.New("sdm: stale object, row is modified by others")
+
+// ErrNoInspector indicates the driver cannot read schema of live database, see
+// driver.Inspector
+var ErrNoInspector = errors.New("sdm: driver does not support schema inspection")
+
+// ErrNoMigrator indicates the driver cannot alter tables, see driver.Migrator
+var ErrNoMigrator = errors.New("sdm: driver does not support schema migration")
+
+// ErrNoRebuilder indicates a table has to be rebuilt to migrate, but the driver
+// does not support it, see driver.Rebuilder
+var ErrNoRebuilder = errors.New("sdm: driver does not support rebuilding table")
+
+// ErrRegister indicates something goes wrong when registering a type
+//
+// Use errors.Is with ErrNotStruct, ErrEmptyColumn and others to check the reason.
+type ErrRegister struct {
+	Type   reflect.Type
+	Field  string // name of struct field causing error, empty if not field-related
+	Reason error
+}
+
+func (e *ErrRegister) Error() string {
+	ret := "sdm: cannot register " + e.Type.String()
+	if e.Field != "" {
+		ret += "." + e.Field
+	}
+	// reasons are prefixed with "sdm: " like other errors
+	return ret + ": " + strings.TrimPrefix(e.Reason.Error(), "sdm: ")
+}
+
+func (e *ErrRegister) Unwrap() error {
+	return e.Reason
+}
+
+// ErrNotRegistered indicates the type is not registered and auto register is
+// not enabled
+type ErrNotRegistered struct {
+	Type reflect.Type
+}
+
+func (e *ErrNotRegistered) Error() string {
+	return "sdm: info of type " + e.Type.String() + " not found"
+}
+
+// ErrRefNotCreated indicates a table is not created by Manager.CreateTables
+// since a table it references failed to be created
+var ErrRefNotCreated = errors.New("sdm: referenced table is not created")
+
+// ErrCreateTables reports tables failed to be created by Manager.CreateTables
+// and Manager.CreateTablesNotExist
+type ErrCreateTables struct {
+	Created []string         // tables successfully created, in creation order
+	Failed  map[string]error // table name => error
+}
+
+func (e *ErrCreateTables) Error() string {
+	return "sdm: cannot create tables: " + failedTables(e.Failed)
+}
+
+// ErrDropTables reports tables failed to be dropped by Manager.DropTables
+type ErrDropTables struct {
+	Dropped []string         // tables successfully dropped, in dropping order
+	Failed  map[string]error // table name => error
+}
+
+func (e *ErrDropTables) Error() string {
+	return "sdm: cannot drop tables: " + failedTables(e.Failed)
+}
+
+// failedTables formats table name => error map, sorted by name
+func failedTables(failed map[string]error) string {
+	names := make([]string, 0, len(failed))
+	for n := range failed {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	ret := make([]string, len(names))
+	for i, n := range names {
+		ret[i] = n + ": " + failed[n].Error()
+	}
+	return strings.Join(ret, "; ")
+}
@@ -12,6 +12,9 @@ import "reflect"
 type Extension interface {
 	// Mean to be called by sdm.Manager. An extension might not work
 	// before initialized.
+	//
+	// Only top-level fields are passed in mapFieldToColumn, columns from
+	// embedded structs are not.
 	Init(typ reflect.Type, mapFieldToColumn map[int]string)
 }
 
@@ -45,7 +48,11 @@ func (m *Manager) Ext(data interface{}, e Extension) {
 
 	cols := make(map[int]string)
 	for _, c := range info.Fields {
-		cols[c.ID] = c.Name
+		if len(c.Index) != 1 {
+			// fields of embedded struct are not supported
+			continue
+		}
+		cols[c.Index[0]] = c.Name
 	}
 
 	e.Init(t, cols)
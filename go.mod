@@ -1,5 +1,7 @@
 module github.com/Ronmi/sdm
 
+go 1.18
+
 require (
 	github.com/go-sql-driver/mysql v1.4.1
 	github.com/mattn/go-sqlite3 v1.10.0
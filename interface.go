@@ -1,6 +1,7 @@
 package sdm
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/Ronmi/sdm/driver"
@@ -13,14 +14,48 @@ import (
 type Executable interface {
 	SQLIn(arr interface{}) string
 	Query(typ interface{}, qstr string, args ...interface{}) *Rows
+	Find(typ interface{}, where string, args ...interface{}) *Rows
+	FindWithDeleted(typ interface{}, where string, args ...interface{}) *Rows
 	QueryRow(data interface{}, qstr string, args ...interface{}) error
 	Prepare(data interface{}, qstr string) (*Stmt, error)
 	PrepareSQL(data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error)
 	Insert(data interface{}) (sql.Result, error)
 	Update(data interface{}, where string, whereargs ...interface{}) (sql.Result, error)
 	Delete(data interface{}) (sql.Result, error)
+	HardDelete(data interface{}) (sql.Result, error)
+	UpdateColumns(data interface{}, cols []string, where string, whereargs ...interface{}) (sql.Result, error)
+	UpdateChanged(data interface{}) (sql.Result, error)
+	UpdateByPK(data interface{}) (sql.Result, error)
+	DeleteByPK(data interface{}) (sql.Result, error)
+	Save(data interface{}) (sql.Result, error)
 	RunBulk(b Bulk) (sql.Result, error)
 	Val(data interface{}) []interface{}
 	ValIns(data interface{}) []interface{}
 	Stmt(stmt *Stmt) (ret *Stmt)
 }
+
+// ExecutableContext is context-aware counterpart of Executable.
+//
+// Both Manager and Tx implement it, so you can cancel queries or apply
+// deadlines no matter you are in transaction or not.
+type ExecutableContext interface {
+	Executable
+	QueryContext(ctx context.Context, typ interface{}, qstr string, args ...interface{}) *Rows
+	FindContext(ctx context.Context, typ interface{}, where string, args ...interface{}) *Rows
+	FindWithDeletedContext(ctx context.Context, typ interface{}, where string, args ...interface{}) *Rows
+	QueryRowContext(ctx context.Context, data interface{}, qstr string, args ...interface{}) error
+	PrepareContext(ctx context.Context, data interface{}, qstr string) (*Stmt, error)
+	PrepareSQLContext(ctx context.Context, data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error)
+	ExecContext(ctx context.Context, qstr string, args ...interface{}) (sql.Result, error)
+	InsertContext(ctx context.Context, data interface{}) (sql.Result, error)
+	UpdateContext(ctx context.Context, data interface{}, where string, whereargs ...interface{}) (sql.Result, error)
+	DeleteContext(ctx context.Context, data interface{}) (sql.Result, error)
+	HardDeleteContext(ctx context.Context, data interface{}) (sql.Result, error)
+	UpdateColumnsContext(ctx context.Context, data interface{}, cols []string, where string, whereargs ...interface{}) (sql.Result, error)
+	UpdateChangedContext(ctx context.Context, data interface{}) (sql.Result, error)
+	UpdateByPKContext(ctx context.Context, data interface{}) (sql.Result, error)
+	DeleteByPKContext(ctx context.Context, data interface{}) (sql.Result, error)
+	SaveContext(ctx context.Context, data interface{}) (sql.Result, error)
+	RunBulkContext(ctx context.Context, b Bulk) (sql.Result, error)
+	StmtContext(ctx context.Context, stmt *Stmt) (ret *Stmt)
+}
@@ -1,38 +1,38 @@
 package sdm
 
 import (
+	"context"
 	"database/sql"
+	sqlDriver "database/sql/driver"
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Ronmi/sdm/driver"
 )
 
-// return -1 if not found
-func findIndexByName(i *[]driver.Index, name, typ string) int {
-	arr := *i
-	for idx, _ := range arr {
-		if arr[idx].Name == name {
-			return idx
-		}
-	}
-
-	idx := len(arr)
-	*i = append(arr, driver.Index{
-		Type: typ,
-		Name: name,
-	})
-	return idx
+// conn abstracts common methods of *sql.DB and *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, qstr string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, qstr string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, qstr string) (*sql.Stmt, error)
 }
 
 type tableInfo struct {
+	Type    reflect.Type
 	Table   string
 	Indexes []driver.Index
 	Defs    map[string]driver.Column
 	Fields  []driver.Column
-	PKIndex int // < 0 if not exists
+	PKIndex int      // < 0 if not exists
+	Ver     string   // version column for optimistic locking, empty if not exists
+	SoftDel string   // soft delete column, empty if not exists
+	Created string   // creation time column, empty if not exists
+	Updated string   // modification time column, empty if not exists
+	Was     []string // previous table names
 }
 
 // Manager is just manager. any question?
@@ -40,16 +40,30 @@ type tableInfo struct {
 // Manager IS NOT ZERO VALUE SAFE. Always create with New()
 //
 // Most methods panic when target type is not registered.
-// You can set AutoReg to prevent panic, but it's not recommended.
+// You can set AutoReg to prevent panic, but it's not recommended. Methods
+// with "E" suffix (ColE, ValE, QueryE...) return *ErrNotRegistered instead.
 type Manager struct {
 	// Automatic register new type with Reg()
 	// Use it with care, since Reg() panics if type has no SDM tag.
 	AutoReg bool
 
-	info map[reflect.Type]*tableInfo
-	lock sync.RWMutex
-	db   *sql.DB
-	drv  driver.Driver
+	// Return ErrNoRowsAffected if Delete, DeleteByPK or bulk delete matches nothing
+	StrictDelete bool
+
+	// Clock for created/updated/softdel columns, time.Now is used if nil
+	Now func() time.Time
+
+	// Rewrite "?" in hand-written SQL into native placeholders of driver, see
+	// Placeholders.Rebind. It affects Query, QueryRow, Find, Exec, Prepare and
+	// where clause of Update, in transaction or not.
+	Rebind bool
+
+	info      map[reflect.Type]*tableInfo
+	lock      sync.RWMutex
+	db        *sql.DB
+	drv       driver.Driver
+	tracked   map[interface{}]*snapshot
+	trackLock sync.Mutex
 }
 
 // New create sdm manager
@@ -60,14 +74,19 @@ type Manager struct {
 // Typical driverStr is "driverName" or "driverName:param1=value1;param2=value2",
 // basiclly same as DSN format.
 func New(db *sql.DB, driverStr string) *Manager {
-	sdmDriver := driver.GetDriver(driverStr)
+	return NewWithDriver(db, driver.GetDriver(driverStr))
+}
 
+// NewWithDriver creates sdm manager with the driver instance, which is useful to
+// create another manager sharing connection and driver with existing one
+//
+//     other := sdm.NewWithDriver(m.Connection(), m.Driver())
+func NewWithDriver(db *sql.DB, drv driver.Driver) *Manager {
 	return &Manager{
-		false,
-		map[reflect.Type]*tableInfo{},
-		sync.RWMutex{},
-		db,
-		sdmDriver,
+		info:    map[reflect.Type]*tableInfo{},
+		db:      db,
+		drv:     drv,
+		tracked: map[interface{}]*snapshot{},
 	}
 }
 
@@ -76,147 +95,40 @@ func (m *Manager) Driver() driver.Driver {
 	return m.drv
 }
 
-func (m *Manager) has(t reflect.Type) bool {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
-	if _, ok := m.info[t]; ok {
-		return true
-	}
-	return false
-}
-
-// Reg calls Register for you, just for short. It panics at first error
-//
-// It will use struct name (convert to lower case) as table name.
-func (m *Manager) Reg(data ...interface{}) {
-	for _, i := range data {
-		t := reflect.Indirect(reflect.ValueOf(i)).Type()
-		m.register(t, strings.ToLower(t.Name()))
-	}
-}
-
-// Register parses and caches a type into SDM. It panics at first error
-func (m *Manager) Register(i interface{}, tableName string) {
-	t := reflect.Indirect(reflect.ValueOf(i)).Type()
-	m.register(t, tableName)
-}
-
-func (m *Manager) register(t reflect.Type, tableName string) {
-	if m.has(t) {
-		return
-	}
-	m.lock.Lock()
-	defer m.lock.Unlock()
-
-	if t.Kind() != reflect.Struct {
-		panic(errors.New("sdm: " + t.String() + " is not a struct type"))
-	}
-
-	mps := make([]driver.Column, 0, t.NumField())
-	idx := make(map[string]driver.Column)
-	indexes := []driver.Index{}
-
-	// some flags
-	havePK := false
-	var lastAIField *driver.Column
-	aiFieldCnt := 0
-
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		tag := f.Tag.Get("sdm")
-		if tag == "" {
-			// not decorated, skip
-			continue
-		}
-
-		if f.Name[0] < 'A' || f.Name[0] > 'Z' {
-			// not exported, skip
-			continue
-		}
-
-		tags := strings.Split(tag, ",")
-		col := tags[0]
-		tags = tags[1:]
-
-		fdef := driver.Column{ID: i, Name: col}
-		for _, tag := range tags {
-
-			if tag == "ai" {
-				fdef.AI = true
-				aiFieldCnt++
-				lastAIField = &fdef
-				continue
-			}
-
-			for _, t := range []string{driver.IndexTypeIndex, driver.IndexTypePrimary, driver.IndexTypeUnique} {
-				l := len(t) + 1
-				if len(tag) < l {
-					continue
-				}
-
-				if !strings.HasPrefix(tag, t+"_") {
-					continue
-				}
-
-				name := tag[l:]
-				pos := findIndexByName(&indexes, name, t)
-				indexes[pos].Cols = append(indexes[pos].Cols, col)
-				if t == driver.IndexTypePrimary {
-					havePK = true
-				}
-				break
-			}
-		}
-
-		mps = append(mps, fdef)
-		idx[col] = fdef
-	}
-
-	if !havePK && aiFieldCnt == 1 {
-		// Use AI field as primary key
-		indexes = append(indexes, driver.Index{
-			Type: driver.IndexTypePrimary,
-			Name: tableName + "_" + "pk",
-			Cols: []string{lastAIField.Name},
-		})
-		havePK = true
-	}
-
-	pk := -1
-	if havePK {
-		for x, i := range indexes {
-			if i.Type == driver.IndexTypePrimary {
-				pk = x
-				break
-			}
-		}
-	}
-
-	m.info[t] = &tableInfo{
-		Table:   tableName,
-		Indexes: indexes,
-		Defs:    idx,
-		Fields:  mps,
-		PKIndex: pk,
+func (m *Manager) getInfo(t reflect.Type) (ret *tableInfo) {
+	ret, err := m.lookupInfo(t)
+	if err != nil {
+		panic(err)
 	}
+	return
 }
 
-func (m *Manager) getInfo(t reflect.Type) (ret *tableInfo) {
+func (m *Manager) lookupInfo(t reflect.Type) (ret *tableInfo, err error) {
 	m.lock.RLock()
 	ret, ok := m.info[t]
 	m.lock.RUnlock()
 
 	if !ok {
 		if !m.AutoReg {
-			panic("info of type " + t.String() + " not found")
+			return nil, &ErrNotRegistered{Type: t}
 		}
 
-		m.register(t, strings.ToLower(t.Name()))
-		return m.getInfo(t)
+		if err = m.registerE(t, strings.ToLower(t.Name())); err != nil {
+			return
+		}
+		return m.lookupInfo(t)
 	}
 	return
 }
 
+func (m *Manager) infoOf(data interface{}) *tableInfo {
+	return m.getInfo(reflect.Indirect(reflect.ValueOf(data)).Type())
+}
+
+func (m *Manager) lookupInfoOf(data interface{}) (*tableInfo, error) {
+	return m.lookupInfo(reflect.Indirect(reflect.ValueOf(data)).Type())
+}
+
 func (m *Manager) getPK(t reflect.Type) (ret driver.Index, ok bool) {
 	info := m.getInfo(t)
 
@@ -234,11 +146,23 @@ func (m *Manager) GetTable(t reflect.Type) (ret string) {
 	return info.Table
 }
 
+// GetTableE is like GetTable, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) GetTableE(t reflect.Type) (ret string, err error) {
+	info, err := m.lookupInfo(t)
+	if err != nil {
+		return
+	}
+	return info.Table, nil
+}
+
 // DropAllTables drops all registered tables, true if all tables are dropped
 //
 // It is here for lazy guys writing tiny applications. The algorithm it use has
 // complexity of O(n!). Yes, slow as fxxk.So you should manage table relations
 // on your own, and drop them by youself.
+//
+// Deprecated: use DropTables, which drops tables in order of foreign key
+// references.
 func (m *Manager) DropAllTables(dropFunc func(table string) error) bool {
 	pending := map[string]bool{}
 	for _, i := range m.info {
@@ -268,49 +192,53 @@ func (m *Manager) DropAllTables(dropFunc func(table string) error) bool {
 // Col returns a list of columns in sql format, including AUTO INCREMENT columns.
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) Col(data interface{}, qType driver.QuotingType) (ret []string) {
-	t := reflect.Indirect(reflect.ValueOf(data)).Type()
-	info := m.getInfo(t)
-	fdef := info.Fields
-	ret = make([]string, 0, len(fdef))
+	return m.col(m.infoOf(data), qType, false)
+}
 
-	for _, f := range fdef {
-		c := m.drv.Col(info.Table, f.Name, qType)
-		ret = append(ret, c)
+// ColE is like Col, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) ColE(data interface{}, qType driver.QuotingType) (ret []string, err error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return
 	}
-
-	return
+	return m.col(info, qType, false), nil
 }
 
 // ColSel returns a list of columns in sql format, suitable for SELECT query
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) ColSel(data interface{}) (ret []string) {
-	v := reflect.Indirect(reflect.ValueOf(data))
-	t := v.Type()
-	info := m.getInfo(t)
-	fdef := info.Fields
-	ret = make([]string, 0, len(fdef))
-
-	for _, f := range fdef {
-		c := m.drv.Col(info.Table, f.Name, driver.QSelect)
-		ret = append(ret, c)
-	}
+	return m.col(m.infoOf(data), driver.QSelect, false)
+}
 
-	return
+// ColSelE is like ColSel, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) ColSelE(data interface{}) (ret []string, err error) {
+	return m.ColE(data, driver.QSelect)
 }
 
 // ColIns returns a list of columns in sql format, excluding AUTO INCREMENT columns
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) ColIns(data interface{}) (ret []string) {
-	t := reflect.Indirect(reflect.ValueOf(data)).Type()
-	info := m.getInfo(t)
+	return m.col(m.infoOf(data), driver.QInsert, true)
+}
+
+// ColInsE is like ColIns, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) ColInsE(data interface{}) (ret []string, err error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return
+	}
+	return m.col(info, driver.QInsert, true), nil
+}
+
+func (m *Manager) col(info *tableInfo, qType driver.QuotingType, skipAI bool) (ret []string) {
 	fdef := info.Fields
 	ret = make([]string, 0, len(fdef))
 
 	for _, f := range fdef {
-		if f.AI {
+		if skipAI && f.AI {
 			continue
 		}
-		c := m.drv.Col(info.Table, f.Name, driver.QInsert)
+		c := m.drv.Col(info.Table, f.Name, qType)
 		ret = append(ret, c)
 	}
 
@@ -320,81 +248,101 @@ func (m *Manager) ColIns(data interface{}) (ret []string) {
 // Val converts struct to value array
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) Val(data interface{}) []interface{} {
-	var ret []interface{}
-	v := reflect.Indirect(reflect.ValueOf(data))
-	t := v.Type()
-	fdef := m.getInfo(t).Fields
-
-	ret = make([]interface{}, len(fdef))
-	for k, f := range fdef {
-		vfield := v.Field(f.ID)
-		if vsql, ok := m.drv.GetValuer(vfield); ok {
-			ret[k] = vsql
-		} else {
-			ret[k] = vfield.Interface()
-		}
+	return m.val(reflect.Indirect(reflect.ValueOf(data)), m.infoOf(data), false)
+}
+
+// ValE is like Val, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) ValE(data interface{}) ([]interface{}, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
 	}
-	return ret
+	return m.val(reflect.Indirect(reflect.ValueOf(data)), info, false), nil
 }
 
 // ValIns converts struct to value array, skipping auto increment fields
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) ValIns(data interface{}) []interface{} {
-	var ret []interface{}
-	v := reflect.Indirect(reflect.ValueOf(data))
-	t := v.Type()
-	fdef := m.getInfo(t).Fields
+	return m.val(reflect.Indirect(reflect.ValueOf(data)), m.infoOf(data), true)
+}
 
-	ret = make([]interface{}, 0, len(fdef))
+// ValInsE is like ValIns, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) ValInsE(data interface{}) ([]interface{}, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.val(reflect.Indirect(reflect.ValueOf(data)), info, true), nil
+}
+
+func (m *Manager) val(v reflect.Value, info *tableInfo, skipAI bool) []interface{} {
+	fdef := info.Fields
+	ret := make([]interface{}, 0, len(fdef))
 	for _, f := range fdef {
-		if f.AI {
+		if skipAI && f.AI {
 			continue
 		}
 
-		vfield := v.Field(f.ID)
-		var res interface{}
-		if vsql, ok := m.drv.GetValuer(vfield); ok {
-			res = vsql
-		} else {
-			res = vfield.Interface()
-		}
-		ret = append(ret, res)
+		ret = append(ret, m.fieldVal(v, f))
 	}
 	return ret
 }
 
-// Holder converts struct to SQL unnamed placeholders
+// fieldVal converts a field to value passing to database/sql
+func (m *Manager) fieldVal(v reflect.Value, f driver.Column) interface{} {
+	vfield, ok := fieldByIndex(v, f.Index, false)
+	if !ok {
+		// nil embedded pointer
+		return nil
+	}
+
+	if vsql, ok := m.drv.GetValuer(vfield); ok {
+		return vsql
+	}
+	return vfield.Interface()
+}
+
+// Holder converts struct to SQL placeholders, numbered from 1 if driver uses
+// numbered placeholders.
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) Holder(data interface{}) []string {
-	v := reflect.Indirect(reflect.ValueOf(data))
-	t := v.Type()
-	fdef := m.getInfo(t).Fields
+	return m.holder(m.infoOf(data), false, m.Placeholders())
+}
 
-	ret := make([]string, len(fdef))
-	for k, f := range fdef {
-		vfield := v.Field(f.ID)
-		ret[k] = m.drv.GetPlaceholder(vfield.Type())
+// HolderE is like Holder, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) HolderE(data interface{}) ([]string, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
 	}
-	return ret
+	return m.holder(info, false, m.Placeholders()), nil
 }
 
-// HolderIns converts struct to SQL unnamed placeholders and skip auto increment fields
+// HolderIns is like Holder, but skips auto increment fields
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) HolderIns(data interface{}) []string {
-	v := reflect.Indirect(reflect.ValueOf(data))
-	t := v.Type()
-	fdef := m.getInfo(t).Fields
+	return m.holder(m.infoOf(data), true, m.Placeholders())
+}
+
+// HolderInsE is like HolderIns, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) HolderInsE(data interface{}) ([]string, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.holder(info, true, m.Placeholders()), nil
+}
 
+func (m *Manager) holder(info *tableInfo, skipAI bool, p *Placeholders) []string {
+	fdef := info.Fields
 	ret := make([]string, 0, len(fdef))
 	for _, f := range fdef {
-		if f.AI {
+		if skipAI && f.AI {
 			continue
 		}
 
-		vfield := v.Field(f.ID)
-		ret = append(ret, m.drv.GetPlaceholder(vfield.Type()))
+		ret = append(ret, p.Next(info.Type.FieldByIndex(f.Index).Type))
 	}
-
 	return ret
 }
 
@@ -404,7 +352,8 @@ func (m *Manager) Connection() *sql.DB {
 }
 
 func (m *Manager) prepare(
-	p func(string) (*sql.Stmt, error),
+	ctx context.Context,
+	c conn,
 	data interface{},
 	qstr string,
 	t reflect.Type,
@@ -412,7 +361,7 @@ func (m *Manager) prepare(
 	cols []string,
 ) (*Stmt, error) {
 
-	stmt, e := p(qstr)
+	stmt, e := c.PrepareContext(ctx, qstr)
 	return &Stmt{
 		stmt:    stmt,
 		def:     f,
@@ -425,15 +374,30 @@ func (m *Manager) prepare(
 // Prepare wraps sql.DB.Prepare
 // It panics if type is not registered and auto register is not enabled.
 func (m *Manager) Prepare(data interface{}, qstr string) (*Stmt, error) {
+	return m.PrepareContext(context.Background(), data, qstr)
+}
+
+// PrepareContext wraps sql.DB.PrepareContext
+// It panics if type is not registered and auto register is not enabled.
+func (m *Manager) PrepareContext(ctx context.Context, data interface{}, qstr string) (*Stmt, error) {
 	t := reflect.Indirect(reflect.ValueOf(data)).Type()
 	f := m.getInfo(t).Defs
-	return m.prepare(m.Connection().Prepare, data, qstr, t, f, nil)
+	return m.prepare(ctx, m.db, data, m.rebind(qstr), t, f, nil)
 }
 
 // PrepareSQL builds sql query with BuildSQL(), then prepare it
 //
 // It is faster than Prepare(data, BuildSQL()), since it does not depend on sql.Rows.Columns()
 func (m *Manager) PrepareSQL(data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error) {
+	return m.PrepareSQLContext(context.Background(), data, tmpl, qType)
+}
+
+// PrepareSQLContext is context-aware version of PrepareSQL
+func (m *Manager) PrepareSQLContext(ctx context.Context, data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error) {
+	return m.prepareSQL(ctx, m.db, data, tmpl, qType)
+}
+
+func (m *Manager) prepareSQL(ctx context.Context, c conn, data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error) {
 	qstr := m.BuildSQL(data, tmpl, qType)
 	t := reflect.Indirect(reflect.ValueOf(data)).Type()
 	info := m.getInfo(t)
@@ -441,7 +405,7 @@ func (m *Manager) PrepareSQL(data interface{}, tmpl string, qType driver.Quoting
 	for x, c := range info.Fields {
 		cols[x] = c.Name
 	}
-	return m.prepare(m.Connection().Prepare, data, qstr, t, info.Defs, cols)
+	return m.prepare(ctx, c, data, qstr, t, info.Defs, cols)
 }
 
 // Proxify proxies needed methods of sql.Rows
@@ -477,9 +441,40 @@ func (m *Manager) createErrorRow(typ reflect.Type, err error) *Rows {
 //
 // You can use "%table%" as placeholder for table name, %cols% for column names
 func (m *Manager) Query(typ interface{}, qstr string, args ...interface{}) *Rows {
-	t := reflect.Indirect(reflect.ValueOf(typ)).Type()
+	return m.QueryContext(context.Background(), typ, qstr, args...)
+}
+
+// QueryContext is context-aware version of Query
+func (m *Manager) QueryContext(ctx context.Context, typ interface{}, qstr string, args ...interface{}) *Rows {
+	return m.query(ctx, m.db, typ, qstr, args)
+}
+
+// QueryE is like Query, but returns *ErrNotRegistered instead of panicking.
+func (m *Manager) QueryE(typ interface{}, qstr string, args ...interface{}) (*Rows, error) {
+	return m.QueryContextE(context.Background(), typ, qstr, args...)
+}
+
+// QueryContextE is context-aware version of QueryE
+func (m *Manager) QueryContextE(ctx context.Context, typ interface{}, qstr string, args ...interface{}) (*Rows, error) {
+	return m.queryE(ctx, m.db, typ, qstr, args)
+}
+
+func (m *Manager) queryE(ctx context.Context, c conn, typ interface{}, qstr string, args []interface{}) (*Rows, error) {
+	if _, err := m.lookupInfoOf(typ); err != nil {
+		return nil, err
+	}
+	return m.query(ctx, c, typ, qstr, args), nil
+}
+
+func (m *Manager) query(ctx context.Context, c conn, typ interface{}, qstr string, args []interface{}) *Rows {
+	return m.queryNative(ctx, c, typ, m.rebind(qstr), args)
+}
+
+// expand replaces "%table%" and "%cols%" in qstr with table name and columns of
+// typ
+func (m *Manager) expand(typ interface{}, qstr string) string {
 	if strings.Index(qstr, "%table%") != -1 {
-		table := m.GetTable(t)
+		table := m.GetTable(reflect.Indirect(reflect.ValueOf(typ)).Type())
 		qstr = strings.Replace(qstr, "%table%", m.drv.Quote(table), -1)
 	}
 
@@ -487,8 +482,14 @@ func (m *Manager) Query(typ interface{}, qstr string, args ...interface{}) *Rows
 		cols := m.ColSel(typ)
 		qstr = strings.Replace(qstr, "%cols%", strings.Join(cols, ","), 1)
 	}
+	return qstr
+}
 
-	dbrows, err := m.db.Query(qstr, args...)
+// queryNative is like query, but qstr uses native placeholders already, so it
+// is not rebound
+func (m *Manager) queryNative(ctx context.Context, c conn, typ interface{}, qstr string, args []interface{}) *Rows {
+	t := reflect.Indirect(reflect.ValueOf(typ)).Type()
+	dbrows, err := c.QueryContext(ctx, m.expand(typ, qstr), args...)
 	if err != nil {
 		return m.createErrorRow(t, err)
 	}
@@ -501,7 +502,12 @@ func (m *Manager) Query(typ interface{}, qstr string, args ...interface{}) *Rows
 //
 // QueryRow is a wrapper for Query, see Query() for detail.
 func (m *Manager) QueryRow(data interface{}, qstr string, args ...interface{}) error {
-	rows := m.Query(data, qstr, args...)
+	return m.QueryRowContext(context.Background(), data, qstr, args...)
+}
+
+// QueryRowContext is context-aware version of QueryRow
+func (m *Manager) QueryRowContext(ctx context.Context, data interface{}, qstr string, args ...interface{}) error {
+	rows := m.QueryContext(ctx, data, qstr, args...)
 	defer rows.Close()
 
 	if !rows.Next() {
@@ -514,8 +520,16 @@ func (m *Manager) QueryRow(data interface{}, qstr string, args ...interface{}) e
 
 // LoadSimple loads simple table
 //
-// Simple table is a table with single-column primary key
+// Simple table is a table with single-column primary key. Soft deleted rows are
+// not loaded.
+//
+// Use LoadByExample if you need sql.ErrNoRows or composite primary key.
 func (m *Manager) LoadSimple(data, pkVal interface{}) error {
+	return m.LoadSimpleContext(context.Background(), data, pkVal)
+}
+
+// LoadSimpleContext is context-aware version of LoadSimple
+func (m *Manager) LoadSimpleContext(ctx context.Context, data, pkVal interface{}) error {
 	qstr := `SELECT %cols% FROM %table% WHERE `
 	v := reflect.Indirect(reflect.ValueOf(data))
 	if !v.CanSet() {
@@ -532,9 +546,12 @@ func (m *Manager) LoadSimple(data, pkVal interface{}) error {
 	}
 
 	cols := m.getInfo(t).Defs
-	f := v.Field(cols[pk.Cols[0]].ID)
-	qstr += m.drv.Col(m.GetTable(t), pk.Cols[0], driver.QWhere) + `=` + m.drv.GetPlaceholder(f.Type())
-	rows := m.Query(data, qstr, pkVal)
+	f := t.FieldByIndex(cols[pk.Cols[0]].Index)
+	qstr += m.drv.Col(m.GetTable(t), pk.Cols[0], driver.QWhere) + `=` + m.Placeholders().Next(f.Type)
+	if cond := m.aliveCond(m.getInfo(t)); cond != "" {
+		qstr += ` AND ` + cond
+	}
+	rows := m.QueryContext(ctx, data, qstr, pkVal)
 	for rows.Next() {
 		rows.Scan(data)
 	}
@@ -544,7 +561,12 @@ func (m *Manager) LoadSimple(data, pkVal interface{}) error {
 
 // Exec wraps sql.DB.Exec
 func (m *Manager) Exec(qstr string, args ...interface{}) (sql.Result, error) {
-	return m.db.Exec(qstr, args...)
+	return m.ExecContext(context.Background(), qstr, args...)
+}
+
+// ExecContext wraps sql.DB.ExecContext
+func (m *Manager) ExecContext(ctx context.Context, qstr string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, m.rebind(qstr), args...)
 }
 
 // BuildSQL constructs sql query
@@ -559,7 +581,10 @@ func (m *Manager) Exec(qstr string, args ...interface{}) (sql.Result, error) {
 //
 // Rules above is not validated, YOU MUST TAKE CARE OF IT YOURSELF.
 //
-// Custom parameters are not supported, use Exec instead.
+// Custom parameters are not supported, use Exec instead. Numbered placeholders
+// in %vals% and %combined% starts from 1.
+//
+// Created/updated columns are not filled, see Stamp.
 //
 // Order of columns is not guaranteed, use Val/ValIns to generate it. For example:
 //
@@ -593,7 +618,13 @@ func (m *Manager) BuildSQL(data interface{}, tmpl string, qType driver.QuotingTy
 //     qstr := m.BuildSQL(myStruct, `REPLACE INTO %table% (%cols%) VALUES (%vals%)`, driver.QInsert)
 //     return m.Exec(qstr, m.Val(myStruct)...)
 func (m *Manager) Build(data interface{}, tmpl string, qType driver.QuotingType) (sql.Result, error) {
-	return m.Exec(
+	return m.BuildContext(context.Background(), data, tmpl, qType)
+}
+
+// BuildContext is context-aware version of Build
+func (m *Manager) BuildContext(ctx context.Context, data interface{}, tmpl string, qType driver.QuotingType) (sql.Result, error) {
+	return m.ExecContext(
+		ctx,
 		m.BuildSQL(data, tmpl, qType),
 		m.Val(data)...,
 	)
@@ -638,7 +669,7 @@ func (m *Manager) tryFillPK(data interface{}, res sql.Result) {
 			return
 		}
 
-		vf := v.Field(col.ID)
+		vf, _ := fieldByIndex(v, col.Index, true)
 
 		id, err := res.LastInsertId()
 		if err != nil || id < 1 {
@@ -661,68 +692,282 @@ func (m *Manager) tryFillPK(data interface{}, res sql.Result) {
 //   - Prmary key contains exactly ne column.
 //   - The column is AUTO INCREMENT enabled.
 func (m *Manager) Insert(data interface{}) (sql.Result, error) {
-	qstr, vals := m.makeInsert(data)
-	res, err := m.db.Exec(qstr, vals...)
+	return m.InsertContext(context.Background(), data)
+}
+
+// InsertContext is context-aware version of Insert
+func (m *Manager) InsertContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.insert(ctx, m.db, data)
+}
+
+func (m *Manager) insert(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	info := m.infoOf(data)
+	stamped := m.stamp(info, data, m.now(), true)
+	qstr, vals := m.makeInsert(stamped)
+	res, err := m.execInsert(ctx, c, info, qstr, vals)
 	if err == nil {
+		m.writeBack(info, data, stamped, info.Created, info.Updated)
 		m.tryFillPK(data, res)
 	}
 	return res, err
 }
 
-func (m *Manager) makeUpdate(data interface{}, where string, whereargs []interface{}) (qstr string, vals []interface{}) {
-	t := reflect.Indirect(reflect.ValueOf(data)).Type()
-	table := m.GetTable(t)
-	vals = m.ValIns(data)
-	cols := m.ColIns(data)
-	hd := m.HolderIns(data)
-	com := make([]string, len(hd))
-	for k, v := range hd {
-		com[k] = cols[k] + "=" + v
+func (m *Manager) makeUpdate(data interface{}, where string, whereargs []interface{}) (qstr string, vals []interface{}, err error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	info := m.getInfo(v.Type())
+	cols := make([]string, 0, len(info.Fields))
+	for _, f := range info.Fields {
+		if !f.AI && f.Name != info.Created {
+			cols = append(cols, f.Name)
+		}
 	}
 
-	qstr = `UPDATE ` + m.drv.Quote(table) +
-		` SET ` + strings.Join(com, ",") +
-		` WHERE ` + where
-	if len(whereargs) > 0 {
-		vals = append(vals, whereargs...)
-	}
-	return
+	return m.makeUpdateSQL(info, v, cols, true, m.rebind(where), whereargs)
 }
 
 // Update updates data in db.
 // It panics if type is not registered and auto register is not enabled.
+//
+// If the type has a version column (tagged with "ver"), it is increased instead
+// of being written, and "AND ver=?" is appended to where. ErrStaleObject is
+// returned if nothing is updated, otherwise version field of data is increased
+// (requires data to be a pointer).
+//
+// If driver uses numbered placeholders, number them in where from 1 like
+// "id=$1", placeholders of SET clause are numbered after whereargs.
 func (m *Manager) Update(data interface{}, where string, whereargs ...interface{}) (sql.Result, error) {
-	qstr, vals := m.makeUpdate(data, where, whereargs)
-	return m.db.Exec(qstr, vals...)
+	return m.UpdateContext(context.Background(), data, where, whereargs...)
+}
+
+// UpdateContext is context-aware version of Update
+func (m *Manager) UpdateContext(ctx context.Context, data interface{}, where string, whereargs ...interface{}) (sql.Result, error) {
+	return m.update(ctx, m.db, data, where, whereargs)
+}
+
+func (m *Manager) update(ctx context.Context, c conn, data interface{}, where string, whereargs []interface{}) (sql.Result, error) {
+	info := m.infoOf(data)
+	stamped := m.stamp(info, data, m.now(), false)
+	qstr, vals, err := m.makeUpdate(stamped, where, whereargs)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.ExecContext(ctx, qstr, vals...)
+	if res, err = m.checkVer(info, data, res, err); err == nil {
+		m.writeBack(info, data, stamped, info.Updated)
+	}
+	return res, err
+}
+
+func (m *Manager) makeUpdateColumns(data interface{}, cols []string, where string, whereargs []interface{}) (qstr string, vals []interface{}, err error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	info, err := m.lookupInfo(v.Type())
+	if err != nil {
+		return
+	}
+
+	return m.makeUpdateSQL(info, v, cols, false, m.rebind(where), whereargs)
+}
+
+// makeUpdateSQL generates UPDATE statement of specified columns. If lock is
+// true, version column is increased and checked instead.
+func (m *Manager) makeUpdateSQL(info *tableInfo, v reflect.Value, cols []string, lock bool, where string, whereargs []interface{}) (qstr string, vals []interface{}, err error) {
+	lock = lock && info.Ver != ""
+	if lock {
+		cols = withoutCol(cols, info.Ver)
+	}
+	if len(cols) == 0 && !lock {
+		return "", nil, ErrNoColumn
+	}
+	if info.Updated != "" {
+		cols = append(withoutCol(cols, info.Updated), info.Updated)
+	}
+
+	// numbered placeholders in where are allocated first, so generated ones
+	// are numbered after whereargs
+	p := m.Placeholders()
+	if p.Numbered() {
+		p.Skip(len(whereargs))
+	}
+	set, setVals, err := m.setClause(info, v, cols, p)
+	if err != nil {
+		return
+	}
+
+	if p.Numbered() {
+		vals = append(whereargs[:len(whereargs):len(whereargs)], setVals...)
+	} else {
+		vals = append(setVals, whereargs...)
+	}
+
+	if lock {
+		if set != "" {
+			set += ","
+		}
+		set += m.verSet(info)
+		cond, ver := m.verCond(info, v, p)
+		where = "(" + where + ") AND " + cond
+		vals = append(vals, ver)
+	}
+
+	qstr = `UPDATE ` + m.drv.Quote(info.Table) +
+		` SET ` + set +
+		` WHERE ` + where
+	return
+}
+
+// setClause generates "col1=?,col2=?" for specified columns
+func (m *Manager) setClause(info *tableInfo, v reflect.Value, cols []string, p *Placeholders) (set string, vals []interface{}, err error) {
+	com := make([]string, len(cols))
+	vals = make([]interface{}, len(cols))
+	for x, c := range cols {
+		f, ok := info.Defs[c]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownColumn, c)
+		}
+
+		com[x] = m.drv.Col(info.Table, c, driver.QUpdate) + "=" +
+			p.Next(info.Type.FieldByIndex(f.Index).Type)
+		vals[x] = m.fieldVal(v, f)
+	}
+
+	return strings.Join(com, ","), vals, nil
+}
+
+// UpdateColumns is like Update, but only specified columns are written.
+//
+// It is useful to prevent lost updates when different parts of program modify
+// different columns of same row. ErrUnknownColumn is returned if some column is
+// not defined in the type, and ErrNoColumn if cols is empty.
+//
+// Version column is not checked nor increased, list it in cols if you want to
+// write it. Modification time column is always written.
+func (m *Manager) UpdateColumns(data interface{}, cols []string, where string, whereargs ...interface{}) (sql.Result, error) {
+	return m.UpdateColumnsContext(context.Background(), data, cols, where, whereargs...)
+}
+
+// UpdateColumnsContext is context-aware version of UpdateColumns
+func (m *Manager) UpdateColumnsContext(ctx context.Context, data interface{}, cols []string, where string, whereargs ...interface{}) (sql.Result, error) {
+	return m.updateColumns(ctx, m.db, data, cols, where, whereargs)
+}
+
+func (m *Manager) updateColumns(ctx context.Context, c conn, data interface{}, cols []string, where string, whereargs []interface{}) (sql.Result, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
+	}
+	stamped := m.stamp(info, data, m.now(), false)
+	qstr, vals, err := m.makeUpdateColumns(stamped, cols, where, whereargs)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.ExecContext(ctx, qstr, vals...)
+	if err == nil {
+		m.writeBack(info, data, stamped, info.Updated)
+	}
+	return res, err
 }
 
 func (m *Manager) makeDelete(data interface{}) (qstr string, vals []interface{}) {
 	t := reflect.Indirect(reflect.ValueOf(data)).Type()
 	table := m.GetTable(t)
-	vals = m.Val(data)
-	cols := m.Col(data, driver.QWhere)
-	hd := m.Holder(data)
-	com := make([]string, len(hd))
-	for k, v := range hd {
-		com[k] = cols[k] + "=" + v
-	}
+	cond, vals := m.matchCond(data, m.Placeholders())
 
 	qstr = `DELETE FROM ` + m.drv.Quote(table) +
-		` WHERE ` + strings.Join(com, " AND ")
+		` WHERE ` + cond
 
 	return
 }
 
-// Delete deletes data in db.
+// matchCond generates NULL-aware WHERE clause matching every column of data
+func (m *Manager) matchCond(data interface{}, p *Placeholders) (cond string, vals []interface{}) {
+	info := m.infoOf(data)
+	all := m.Val(data)
+	cols := m.Col(data, driver.QWhere)
+	com := make([]string, len(info.Fields))
+	vals = make([]interface{}, 0, len(all))
+	for k, f := range info.Fields {
+		if isNull(all[k]) {
+			com[k] = cols[k] + " IS NULL"
+			continue
+		}
+		// allocate only if used, or numbered placeholders are skipped
+		com[k] = cols[k] + "=" + p.Next(info.Type.FieldByIndex(f.Index).Type)
+		vals = append(vals, all[k])
+	}
+
+	return strings.Join(com, " AND "), vals
+}
+
+// isNull determins if a value (returned by Val) represents NULL
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	if valuer, ok := v.(sqlDriver.Valuer); ok {
+		if x, err := valuer.Value(); err == nil && x == nil {
+			return true
+		}
+		return false
+	}
+
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// checkAffected returns ErrNoRowsAffected if delete matched nothing in strict mode
+func (m *Manager) checkAffected(res sql.Result, err error) (sql.Result, error) {
+	if err != nil || !m.StrictDelete {
+		return res, err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return res, ErrNoRowsAffected
+	}
+	return res, nil
+}
+
+// Delete deletes data in db, matching every column. Nil pointers are matched
+// with IS NULL.
 // It panics if type is not registered and auto register is not enabled.
+//
+// If the type has a soft delete column (tagged with "softdel"), rows are marked
+// as deleted with current time instead, and the field is filled if data is a
+// pointer. Use HardDelete to really delete them.
+//
+// ErrNoRowsAffected is returned if nothing is deleted and StrictDelete is set.
 func (m *Manager) Delete(data interface{}) (sql.Result, error) {
-	qstr, vals := m.makeDelete(data)
-	return m.db.Exec(qstr, vals...)
+	return m.DeleteContext(context.Background(), data)
+}
+
+// DeleteContext is context-aware version of Delete
+func (m *Manager) DeleteContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.delete(ctx, m.db, data)
+}
+
+func (m *Manager) delete(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	info := m.infoOf(data)
+	if info.SoftDel == "" {
+		return m.hardDelete(ctx, c, data)
+	}
+
+	now := m.now()
+	qstr, vals := m.makeSoftDelete(data, now)
+	res, err := m.checkAffected(c.ExecContext(ctx, qstr, vals...))
+	if err == nil {
+		m.markDeleted(info, data, now)
+	}
+	return res, err
 }
 
 // Begin creates a transaction
 func (m *Manager) Begin() (*Tx, error) {
-	tx, err := m.db.Begin()
+	return m.BeginTx(context.Background(), nil)
+}
+
+// BeginTx creates a transaction with context and options, see sql.DB.BeginTx
+func (m *Manager) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := m.db.BeginTx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -730,43 +975,72 @@ func (m *Manager) Begin() (*Tx, error) {
 }
 
 // BulkInsert creates a generator to generate long statement which inserts many data at once
+//
+// Created/updated timestamps are filled back to added data only after RunBulk
+// succeeds, Make does not modify them.
 func (m *Manager) BulkInsert(typ interface{}) Bulk {
 	t := reflect.Indirect(reflect.ValueOf(typ)).Type()
 	table := m.GetTable(t)
 
 	return &bulkInsert{
 		newBulkInfo(table, t, m),
+		nil,
 	}
 }
 
 // BulkDelete creates a generator to generate long statement which deletes many data at once
 // It panics if type is not registered and auto register is not enabled.
+//
+// Like Delete, rows are marked as deleted if the type supports soft delete, but
+// fields of data are not filled.
 func (m *Manager) BulkDelete(typ interface{}) Bulk {
 	t := reflect.Indirect(reflect.ValueOf(typ)).Type()
 	table := m.GetTable(t)
 
 	return &bulkDelete{
 		newBulkInfo(table, t, m),
+		false,
+	}
+}
+
+// BulkHardDelete is like BulkDelete, but always removes rows from db like
+// HardDelete, even if the type supports soft delete.
+// It panics if type is not registered and auto register is not enabled.
+func (m *Manager) BulkHardDelete(typ interface{}) Bulk {
+	t := reflect.Indirect(reflect.ValueOf(typ)).Type()
+	table := m.GetTable(t)
+
+	return &bulkDelete{
+		newBulkInfo(table, t, m),
+		true,
 	}
 }
 
 // RunBulk executes bulk operations in transaction. It does not return a result when
 // success and panics if bulk implementation goes wrong.
 func (m *Manager) RunBulk(b Bulk) (sql.Result, error) {
+	return m.RunBulkContext(context.Background(), b)
+}
+
+// RunBulkContext is context-aware version of RunBulk
+func (m *Manager) RunBulkContext(ctx context.Context, b Bulk) (sql.Result, error) {
 	if b.Len() < 1 {
 		return nil, nil
 	}
 
-	tx, err := m.Begin()
+	tx, err := m.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	ret, err := tx.RunBulk(b)
+	ret, err := tx.runBulk(ctx, b)
 	if err == nil {
 		err = tx.Commit()
 	}
+	if w, ok := b.(bulkWriter); ok && err == nil {
+		w.writeBack()
+	}
 
 	return ret, err
 }
@@ -775,13 +1049,11 @@ func (m *Manager) RunBulk(b Bulk) (sql.Result, error) {
 //
 //     users = []int{1, 2, 3}
 //     qstr := `SELECT %cols% FROM %table% WHERE id ` + m.SQLIn(users)
+//
+// Numbered placeholders starts from 1, use Placeholders if there are other
+// parameters.
 func (m *Manager) SQLIn(arr interface{}) (ret string) {
-	v := reflect.ValueOf(arr)
-	sz := v.Len()
-	typ := v.Type().Elem()
-	holder := m.Driver().GetPlaceholder(typ)
-	holders := strings.Repeat(holder+",", sz)
-	return `IN (` + holders[:len(holders)-1] + `)`
+	return m.Placeholders().In(arr)
 }
 
 // AsArgs converts any array/slice/map to []interface{} panics if not these type
@@ -808,3 +1080,10 @@ func (m *Manager) KeyAsArgs(arr interface{}) []interface{} {
 func (m *Manager) Stmt(stmt *Stmt) (ret *Stmt) {
 	return stmt
 }
+
+// StmtContext just returns what you passed in.
+//
+// It is here to implement ExecutableContext interface.
+func (m *Manager) StmtContext(ctx context.Context, stmt *Stmt) (ret *Stmt) {
+	return stmt
+}
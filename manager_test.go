@@ -1,6 +1,7 @@
 package sdm
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"reflect"
@@ -484,3 +485,84 @@ func TestManager(t *testing.T) {
 		})
 	}
 }
+
+var _ ExecutableContext = &Manager{}
+var _ ExecutableContext = &Tx{}
+
+func TestManagerContext(t *testing.T) {
+	_, m := initdb(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := m.InsertContext(ctx, testai{ExportString: "canceled"}); err == nil {
+		t.Error("expected InsertContext to fail with canceled context")
+	}
+
+	rows := m.QueryContext(ctx, testai{}, `SELECT %cols% FROM %table%`)
+	defer rows.Close()
+	if rows.Err() == nil {
+		t.Error("expected QueryContext to fail with canceled context")
+	}
+
+	if _, err := m.BeginTx(ctx, nil); err == nil {
+		t.Error("expected BeginTx to fail with canceled context")
+	}
+
+	data := testai{ExportString: "alive"}
+	if _, err := m.InsertContext(context.Background(), &data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var actual testai
+	if err := m.QueryRowContext(context.Background(), &actual, `SELECT %cols% FROM %table% WHERE eint=?`, data.ExportInt); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if actual.ExportString != "alive" {
+		t.Errorf("expected estr to be 'alive', got '%s'", actual.ExportString)
+	}
+}
+
+type testnullable struct {
+	ID   int        `sdm:"id"`
+	Name *string    `sdm:"name"`
+	T    *time.Time `sdm:"t"`
+}
+
+func TestDeleteNull(t *testing.T) {
+	db := newdb(t)
+	m := New(db, "sqlite3:time=int")
+	m.Reg(testnullable{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create table: %s", err)
+	}
+
+	name := "name"
+	data := testnullable{ID: 1, Name: &name}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	qstr, vals := m.makeDelete(data)
+	expect := `DELETE FROM "testnullable" WHERE "testnullable"."id"=? AND "testnullable"."name"=? AND "testnullable"."t" IS NULL`
+	if qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+	if l := len(vals); l != 2 {
+		t.Errorf("expected 2 values, got %d", l)
+	}
+
+	res, err := m.Delete(data)
+	if err != nil {
+		t.Fatalf("cannot delete: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("expected 1 row deleted, got %d", n)
+	}
+
+	if _, err = m.Delete(data); err != nil {
+		t.Errorf("expected no error in non-strict mode, got %s", err)
+	}
+	m.StrictDelete = true
+	if _, err = m.Delete(data); err != ErrNoRowsAffected {
+		t.Errorf("expected ErrNoRowsAffected in strict mode, got %v", err)
+	}
+}
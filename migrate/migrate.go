@@ -0,0 +1,468 @@
+// Package migrate runs versioned schema migrations with SDM.
+//
+// Migrations are Go functions or SQL files. Applied versions are recorded in
+// a history table, which is created by SDM driver, so it works with any driver
+// supporting table creation.
+//
+//     r := migrate.New(m)
+//     r.Add(migrate.Migration{
+//         Version: 1,
+//         Name:    "create users",
+//         Up:      migrate.SQL(`CREATE TABLE users (id INTEGER, name TEXT)`),
+//         Down:    migrate.SQL(`DROP TABLE users`),
+//     })
+//     applied, err := r.Up()
+//
+// SQL files are loaded from an fs.FS with AddFS. File names must be like
+// "0001_create_users.up.sql" and "0001_create_users.down.sql".
+//
+// Each migration runs in a transaction along with the history record. Note that
+// some databases, like MySQL, commit DDL statements implicitly, so a failed
+// migration might be partially applied.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Ronmi/sdm"
+	"github.com/Ronmi/sdm/driver"
+)
+
+// DefaultTable is the default name of history table
+const DefaultTable = "sdm_migrations"
+
+// Possible errors
+var (
+	ErrLocked            = errors.New("migrate: another runner is running")
+	ErrDuplicateVersion  = errors.New("migrate: duplicated version")
+	ErrIrreversible      = errors.New("migrate: migration cannot be reverted")
+	ErrUnknownVersion    = errors.New("migrate: applied version not found in migrations")
+	ErrInvalidMigration  = errors.New("migrate: invalid migration")
+	ErrInvalidMigrations = errors.New("migrate: invalid migration files")
+)
+
+// Migration represents a versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *sdm.Tx) error
+	Down    func(tx *sdm.Tx) error // nil if irreversible
+}
+
+// Record represents an applied migration in history table
+type Record struct {
+	Version   int64     `sdm:"version"`
+	Name      string    `sdm:"name"`
+	AppliedAt time.Time `sdm:"applied_at"`
+}
+
+// lock is the only row in lock table while a runner is running
+type lock struct {
+	ID       int       `sdm:"id"`
+	LockedAt time.Time `sdm:"locked_at"`
+}
+
+// tableType creates a struct type convertible to typ, with first field as
+// primary key named after table. Runners using different tables therefore do
+// not create constraints with same name.
+func tableType(typ reflect.Type, table string) reflect.Type {
+	fields := make([]reflect.StructField, typ.NumField())
+	for x := range fields {
+		fields[x] = typ.Field(x)
+	}
+	fields[0].Tag = reflect.StructTag(fmt.Sprintf(
+		`sdm:"%s,pri_%s_pk"`, fields[0].Tag.Get("sdm"), table,
+	))
+	return reflect.StructOf(fields)
+}
+
+// Runner applies and reverts migrations
+//
+// Runners sharing a database are mutually excluded by a lock table, named after
+// history table with "_lock" suffix. A runner fails with ErrLocked instead of
+// waiting if the lock is held by others.
+type Runner struct {
+	m          *sdm.Manager
+	h          *sdm.Manager // holds history and lock table
+	table      string
+	recType    reflect.Type // Record with named primary key
+	lockType   reflect.Type // lock with named primary key
+	migrations []Migration  // sorted by version
+}
+
+// New creates a runner using DefaultTable as history table
+func New(m *sdm.Manager, migrations ...Migration) *Runner {
+	return NewWithTable(m, DefaultTable, migrations...)
+}
+
+// NewWithTable creates a runner with custom history table.
+//
+// History and lock table are registered to a private manager sharing connection
+// and driver with m, so they are not touched by m.DropTables, m.Verify or
+// m.PlanMigration.
+func NewWithTable(m *sdm.Manager, table string, migrations ...Migration) *Runner {
+	r := &Runner{
+		m:        m,
+		h:        sdm.NewWithDriver(m.Connection(), m.Driver()),
+		table:    table,
+		recType:  tableType(reflect.TypeOf(Record{}), table),
+		lockType: tableType(reflect.TypeOf(lock{}), table+"_lock"),
+	}
+	r.h.Register(reflect.New(r.recType).Interface(), table)
+	r.h.Register(reflect.New(r.lockType).Interface(), table+"_lock")
+	r.Add(migrations...)
+	return r
+}
+
+// SQL creates a migration function executing the SQL script. The script is
+// split into statements by semicolon with lexical rules of the driver, see
+// SplitSQL.
+//
+// Statements are executed as is, "?" is not rewritten even if Manager.Rebind
+// is set.
+func SQL(script string) func(tx *sdm.Tx) error {
+	return func(tx *sdm.Tx) error {
+		for _, s := range splitSQL(driver.SyntaxOf(tx.Driver()), script) {
+			if _, err := tx.Tx().Exec(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SQLRaw is like SQL, but executes whole script at once without splitting.
+// Multiple statements in one query must be supported by database/sql driver.
+func SQLRaw(script string) func(tx *sdm.Tx) error {
+	return func(tx *sdm.Tx) error {
+		_, err := tx.Tx().Exec(script)
+		return err
+	}
+}
+
+// Add adds migrations to the runner. It panics if a version is added twice, or
+// a migration has no Up function.
+func (r *Runner) Add(migrations ...Migration) {
+	if err := r.AddE(migrations...); err != nil {
+		panic(err)
+	}
+}
+
+// AddE is like Add, but returns ErrDuplicateVersion or ErrInvalidMigration
+// instead of panicking.
+func (r *Runner) AddE(migrations ...Migration) error {
+	for _, mig := range migrations {
+		if mig.Up == nil {
+			return fmt.Errorf("%w: version %d has no up function", ErrInvalidMigration, mig.Version)
+		}
+		if _, ok := r.find(mig.Version); ok {
+			return fmt.Errorf("%w: %d", ErrDuplicateVersion, mig.Version)
+		}
+		r.migrations = append(r.migrations, mig)
+	}
+
+	sort.Slice(r.migrations, func(a, b int) bool {
+		return r.migrations[a].Version < r.migrations[b].Version
+	})
+	return nil
+}
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// AddFS adds SQL migration files in root directory of fsys, other files are
+// ignored. Use fs.Sub for sub directory.
+//
+// An up file is required for each version, down file is optional.
+func (r *Runner) AddFS(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	found := map[int64]*Migration{}
+	versions := []int64{}
+	for _, e := range entries {
+		match := fileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		ver, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrInvalidMigrations, e.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return err
+		}
+
+		mig, ok := found[ver]
+		if !ok {
+			mig = &Migration{Version: ver, Name: match[2]}
+			found[ver] = mig
+			versions = append(versions, ver)
+		}
+		if mig.Name != match[2] {
+			return fmt.Errorf("%w: version %d has different names", ErrInvalidMigrations, ver)
+		}
+		if match[3] == "up" {
+			mig.Up = SQL(string(data))
+		} else {
+			mig.Down = SQL(string(data))
+		}
+	}
+
+	ret := make([]Migration, 0, len(versions))
+	for _, v := range versions {
+		if found[v].Up == nil {
+			return fmt.Errorf("%w: version %d has no up file", ErrInvalidMigrations, v)
+		}
+		ret = append(ret, *found[v])
+	}
+
+	return r.AddE(ret...)
+}
+
+// Migrations returns added migrations, sorted by version
+func (r *Runner) Migrations() []Migration {
+	return append([]Migration(nil), r.migrations...)
+}
+
+func (r *Runner) find(ver int64) (Migration, bool) {
+	for _, mig := range r.migrations {
+		if mig.Version == ver {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// init creates history and lock table
+func (r *Runner) init() error {
+	return r.h.CreateTablesNotExist()
+}
+
+// acquire initializes the runner and takes the lock
+func (r *Runner) acquire(ctx context.Context) error {
+	if err := r.init(); err != nil {
+		return err
+	}
+
+	l := reflect.ValueOf(lock{ID: 1, LockedAt: time.Now()}).Convert(r.lockType)
+	_, err := r.h.InsertContext(ctx, l.Interface())
+	if err == nil {
+		return nil
+	}
+
+	// check if it fails because of the lock
+	if e := r.h.QueryRowContext(ctx, reflect.New(r.lockType).Interface(), `SELECT %cols% FROM %table%`); e == nil {
+		return ErrLocked
+	}
+	return err
+}
+
+func (r *Runner) release(ctx context.Context) error {
+	_, err := r.h.ExecContext(ctx, `DELETE FROM `+r.h.Driver().Quote(r.table+"_lock"))
+	return err
+}
+
+// releaseTo releases the lock, and reports error to err if nothing goes wrong
+// before
+func (r *Runner) releaseTo(ctx context.Context, err *error) {
+	if e := r.release(ctx); e != nil && *err == nil {
+		*err = e
+	}
+}
+
+// Unlock removes the lock forcibly, which is useful if a runner crashed while
+// holding it.
+func (r *Runner) Unlock() error {
+	if err := r.init(); err != nil {
+		return err
+	}
+	return r.release(context.Background())
+}
+
+// Applied lists applied migrations, sorted by version
+func (r *Runner) Applied() ([]Record, error) {
+	return r.AppliedContext(context.Background())
+}
+
+// AppliedContext is context-aware version of Applied
+func (r *Runner) AppliedContext(ctx context.Context) ([]Record, error) {
+	if err := r.init(); err != nil {
+		return nil, err
+	}
+	return r.applied(ctx)
+}
+
+func (r *Runner) applied(ctx context.Context) (ret []Record, err error) {
+	rec := reflect.New(r.recType)
+	rows := r.h.QueryContext(ctx, rec.Interface(), `SELECT %cols% FROM %table% ORDER BY version`)
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = rows.Scan(rec.Interface()); err != nil {
+			return
+		}
+		ret = append(ret, rec.Elem().Convert(reflect.TypeOf(Record{})).Interface().(Record))
+	}
+
+	return ret, rows.Err()
+}
+
+// Up applies all pending migrations, returns versions applied.
+func (r *Runner) Up() ([]int64, error) {
+	return r.UpToContext(context.Background(), -1)
+}
+
+// UpContext is context-aware version of Up
+func (r *Runner) UpContext(ctx context.Context) ([]int64, error) {
+	return r.UpToContext(ctx, -1)
+}
+
+// UpTo applies pending migrations with version not greater than ver,
+// returns versions applied. Negative ver means all pending migrations.
+func (r *Runner) UpTo(ver int64) ([]int64, error) {
+	return r.UpToContext(context.Background(), ver)
+}
+
+// UpToContext is context-aware version of UpTo
+func (r *Runner) UpToContext(ctx context.Context, ver int64) (ret []int64, err error) {
+	if err = r.acquire(ctx); err != nil {
+		return
+	}
+	defer r.releaseTo(ctx, &err)
+
+	recs, err := r.applied(ctx)
+	if err != nil {
+		return
+	}
+	done := map[int64]bool{}
+	for _, rec := range recs {
+		done[rec.Version] = true
+	}
+
+	for _, mig := range r.migrations {
+		if ver >= 0 && mig.Version > ver {
+			break
+		}
+		if done[mig.Version] {
+			continue
+		}
+		if err = r.run(ctx, mig, true); err != nil {
+			return
+		}
+		ret = append(ret, mig.Version)
+	}
+
+	return
+}
+
+// Down reverts last applied migration, returns version reverted or -1 if
+// nothing is applied.
+func (r *Runner) Down() (int64, error) {
+	return r.DownContext(context.Background())
+}
+
+// DownContext is context-aware version of Down
+func (r *Runner) DownContext(ctx context.Context) (ver int64, err error) {
+	if err = r.acquire(ctx); err != nil {
+		return -1, err
+	}
+	defer r.releaseTo(ctx, &err)
+
+	recs, err := r.applied(ctx)
+	if err != nil || len(recs) == 0 {
+		return -1, err
+	}
+
+	ver = recs[len(recs)-1].Version
+	return ver, r.revert(ctx, ver)
+}
+
+// DownTo reverts applied migrations with version greater than ver, returns
+// versions reverted.
+func (r *Runner) DownTo(ver int64) ([]int64, error) {
+	return r.DownToContext(context.Background(), ver)
+}
+
+// DownToContext is context-aware version of DownTo
+func (r *Runner) DownToContext(ctx context.Context, ver int64) (ret []int64, err error) {
+	if err = r.acquire(ctx); err != nil {
+		return
+	}
+	defer r.releaseTo(ctx, &err)
+
+	recs, err := r.applied(ctx)
+	if err != nil {
+		return
+	}
+
+	for x := len(recs) - 1; x >= 0 && recs[x].Version > ver; x-- {
+		if err = r.revert(ctx, recs[x].Version); err != nil {
+			return
+		}
+		ret = append(ret, recs[x].Version)
+	}
+
+	return
+}
+
+func (r *Runner) revert(ctx context.Context, ver int64) error {
+	mig, ok := r.find(ver)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, ver)
+	}
+	if mig.Down == nil {
+		return fmt.Errorf("%w: %d", ErrIrreversible, ver)
+	}
+	return r.run(ctx, mig, false)
+}
+
+// run applies or reverts a migration in a transaction
+func (r *Runner) run(ctx context.Context, mig Migration, up bool) (err error) {
+	tx, err := r.m.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			err = fmt.Errorf("migrate: version %d (%s): %w", mig.Version, mig.Name, err)
+		}
+	}()
+
+	// history is written by the private manager in same transaction
+	if up {
+		if err = mig.Up(tx); err != nil {
+			return
+		}
+		rec := reflect.ValueOf(Record{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			AppliedAt: time.Now(),
+		}).Convert(r.recType).Interface()
+		qstr := r.h.BuildSQL(rec, `INSERT INTO %table% (%cols%) VALUES (%vals%)`, driver.QInsert)
+		_, err = tx.Tx().ExecContext(ctx, qstr, r.h.Val(rec)...)
+	} else {
+		if err = mig.Down(tx); err != nil {
+			return
+		}
+		d := r.h.Driver()
+		qstr := `DELETE FROM ` + d.Quote(r.table) + ` WHERE ` + d.Quote("version") + `=?`
+		_, err = tx.Tx().ExecContext(ctx, r.h.Placeholders().Rebind(qstr), mig.Version)
+	}
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
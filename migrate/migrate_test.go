@@ -0,0 +1,383 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Ronmi/sdm"
+	"github.com/Ronmi/sdm/driver"
+	_ "github.com/Ronmi/sdm/driver/mysql"
+	_ "github.com/Ronmi/sdm/driver/sqlite3"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newRunner(t *testing.T) (*Runner, *sql.DB) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("cannot open db: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"0001_user.up.sql":   {Data: []byte("CREATE TABLE user (id INTEGER);\nINSERT INTO user VALUES (1);")},
+		"0001_user.down.sql": {Data: []byte("DROP TABLE user;")},
+		"0002_post.up.sql":   {Data: []byte("CREATE TABLE post (id INTEGER, title TEXT DEFAULT 'a;b')")},
+		"0002_post.down.sql": {Data: []byte("DROP TABLE post")},
+		"README.md":          {Data: []byte("ignored")},
+	}
+	r := New(sdm.New(db, "sqlite3"))
+	if err := r.AddFS(fsys); err != nil {
+		t.Fatalf("cannot load migrations: %s", err)
+	}
+	r.Add(Migration{
+		Version: 3,
+		Name:    "go",
+		Up: func(tx *sdm.Tx) error {
+			_, err := tx.Exec(`UPDATE user SET id=2`)
+			return err
+		},
+	})
+
+	return r, db
+}
+
+func versions(t *testing.T, r *Runner) []int64 {
+	recs, err := r.Applied()
+	if err != nil {
+		t.Fatalf("cannot list applied migrations: %s", err)
+	}
+	ret := []int64{}
+	for _, rec := range recs {
+		ret = append(ret, rec.Version)
+	}
+	return ret
+}
+
+func TestUpDown(t *testing.T) {
+	r, db := newRunner(t)
+
+	applied, err := r.UpTo(2)
+	if err != nil {
+		t.Fatalf("cannot migrate to 2: %s", err)
+	}
+	if e := []int64{1, 2}; !reflect.DeepEqual(applied, e) {
+		t.Errorf("expected %v applied, got %v", e, applied)
+	}
+
+	applied, err = r.Up()
+	if err != nil {
+		t.Fatalf("cannot migrate: %s", err)
+	}
+	if e := []int64{3}; !reflect.DeepEqual(applied, e) {
+		t.Errorf("expected %v applied, got %v", e, applied)
+	}
+	if e := []int64{1, 2, 3}; !reflect.DeepEqual(versions(t, r), e) {
+		t.Errorf("expected %v in history, got %v", e, versions(t, r))
+	}
+	var id int
+	if err := db.QueryRow(`SELECT id FROM user`).Scan(&id); err != nil || id != 2 {
+		t.Errorf("expected migrations are applied, got %d, %v", id, err)
+	}
+
+	if applied, err = r.Up(); err != nil || len(applied) != 0 {
+		t.Errorf("expected nothing to apply, got %v, %v", applied, err)
+	}
+
+	if _, err := r.Down(); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("expected ErrIrreversible, got %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM sdm_migrations WHERE version=3`); err != nil {
+		t.Fatalf("cannot remove history: %s", err)
+	}
+
+	ver, err := r.Down()
+	if err != nil || ver != 2 {
+		t.Fatalf("expected 2 reverted, got %d, %v", ver, err)
+	}
+	reverted, err := r.DownTo(0)
+	if err != nil {
+		t.Fatalf("cannot revert all: %s", err)
+	}
+	if e := []int64{1}; !reflect.DeepEqual(reverted, e) {
+		t.Errorf("expected %v reverted, got %v", e, reverted)
+	}
+	if v := versions(t, r); len(v) != 0 {
+		t.Errorf("expected empty history, got %v", v)
+	}
+	if _, err := db.Exec(`SELECT * FROM user`); err == nil {
+		t.Error("expected table user is dropped")
+	}
+}
+
+func TestFailed(t *testing.T) {
+	r, db := newRunner(t)
+	r.Add(Migration{
+		Version: 4,
+		Name:    "bad",
+		Up: func(tx *sdm.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE bad (id INTEGER)`); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO nowhere VALUES (1)`)
+			return err
+		},
+	})
+
+	applied, err := r.Up()
+	if err == nil {
+		t.Fatal("expected migration 4 fails")
+	}
+	if e := []int64{1, 2, 3}; !reflect.DeepEqual(applied, e) {
+		t.Errorf("expected %v applied, got %v", e, applied)
+	}
+	if _, err := db.Exec(`SELECT * FROM bad`); err == nil {
+		t.Error("expected migration 4 is rolled back")
+	}
+
+	// lock must be released
+	if _, err := r.Up(); err == nil {
+		t.Error("expected migration 4 fails again")
+	}
+}
+
+func TestLock(t *testing.T) {
+	r, db := newRunner(t)
+	if err := r.Unlock(); err != nil {
+		t.Fatalf("cannot initialize: %s", err)
+	}
+	if _, err := db.Exec(`INSERT INTO sdm_migrations_lock (id, locked_at) VALUES (1, CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("cannot take lock: %s", err)
+	}
+
+	if _, err := r.Up(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if err := r.Unlock(); err != nil {
+		t.Fatalf("cannot unlock: %s", err)
+	}
+	if _, err := r.Up(); err != nil {
+		t.Fatalf("cannot migrate after unlocking: %s", err)
+	}
+}
+
+// TestHistoryTables checks if history tables are kept out of manager of user,
+// and runners with different tables can share a manager
+func TestHistoryTables(t *testing.T) {
+	r, db := newRunner(t)
+	if _, err := r.Up(); err != nil {
+		t.Fatalf("cannot migrate: %s", err)
+	}
+	if _, err := r.m.GetTableE(reflect.TypeOf(Record{})); err == nil {
+		t.Error("expected history table is not registered to manager")
+	}
+	if dropped, err := r.m.DropTables(); err != nil || len(dropped) != 0 {
+		t.Fatalf("expected nothing dropped, got %v, %v", dropped, err)
+	}
+	if e := []int64{1, 2, 3}; !reflect.DeepEqual(versions(t, r), e) {
+		t.Errorf("expected %v in history, got %v", e, versions(t, r))
+	}
+
+	other := NewWithTable(r.m, "other_migrations", Migration{
+		Version: 1,
+		Name:    "other",
+		Up:      SQL(`SELECT 1`),
+	})
+	if applied, err := other.Up(); err != nil || !reflect.DeepEqual(applied, []int64{1}) {
+		t.Fatalf("expected 1 applied, got %v, %v", applied, err)
+	}
+	var def string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE name='other_migrations'`).Scan(&def); err != nil {
+		t.Fatalf("cannot read table definition: %s", err)
+	}
+	if !strings.Contains(def, `"other_migrations_pk"`) {
+		t.Errorf("expected primary key named after table, got %s", def)
+	}
+}
+
+func TestAddInvalid(t *testing.T) {
+	r, _ := newRunner(t)
+	if err := r.AddE(Migration{Version: 1}); !errors.Is(err, ErrInvalidMigration) {
+		t.Errorf("expected ErrInvalidMigration, got %v", err)
+	}
+	if err := r.AddE(Migration{Version: 1, Up: SQL("")}); !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("expected ErrDuplicateVersion, got %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected Add panics without up function")
+			}
+		}()
+		r.Add(Migration{Version: 9})
+	}()
+
+	err := r.AddFS(fstest.MapFS{"0005_x.down.sql": {Data: []byte("")}})
+	if !errors.Is(err, ErrInvalidMigrations) {
+		t.Errorf("expected ErrInvalidMigrations, got %v", err)
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	script := `
+-- comment; here
+CREATE TABLE a (s TEXT DEFAULT 'x;''y');
+/* block; comment */ INSERT INTO a VALUES ("q;", 'it\'s;');;
+SELECT ` + "`a;b`" + ` FROM a`
+	expect := []string{
+		"-- comment; here\nCREATE TABLE a (s TEXT DEFAULT 'x;''y')",
+		`/* block; comment */ INSERT INTO a VALUES ("q;", 'it\'s;')`,
+		"SELECT `a;b` FROM a",
+	}
+
+	if actual := SplitSQL(script); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expected %q, got %q", expect, actual)
+	}
+}
+
+func TestSplitSQLBlocks(t *testing.T) {
+	cases := []struct {
+		msg    string
+		syn    driver.Syntax
+		script string
+		expect []string
+	}{
+		{
+			msg: "sqlite trigger",
+			script: `CREATE TABLE a (id INTEGER, n INTEGER);
+CREATE TEMP TRIGGER t AFTER INSERT ON a BEGIN
+  UPDATE a SET n = CASE WHEN n > 0 THEN n ELSE 0 END;
+  DELETE FROM a WHERE id < 0;
+END;
+BEGIN;
+END;`,
+			expect: []string{
+				"CREATE TABLE a (id INTEGER, n INTEGER)",
+				"CREATE TEMP TRIGGER t AFTER INSERT ON a BEGIN\n  UPDATE a SET n = CASE WHEN n > 0 THEN n ELSE 0 END;\n  DELETE FROM a WHERE id < 0;\nEND",
+				"BEGIN",
+				"END",
+			},
+		},
+		{
+			msg: "mysql procedure",
+			syn: driver.DefaultSyntax,
+			script: `CREATE DEFINER=` + "`root`@`%`" + ` PROCEDURE p() BEGIN
+  IF 1 THEN SELECT 'a;\';'; END IF;
+  x: LOOP LEAVE x; END LOOP;
+END;
+SELECT 1`,
+			expect: []string{
+				"CREATE DEFINER=`root`@`%` PROCEDURE p() BEGIN\n  IF 1 THEN SELECT 'a;\\';'; END IF;\n  x: LOOP LEAVE x; END LOOP;\nEND",
+				"SELECT 1",
+			},
+		},
+		{
+			msg: "postgres function",
+			syn: driver.Syntax{DollarQuote: true},
+			script: `CREATE FUNCTION f() RETURNS trigger AS $body$
+BEGIN
+  NEW.s := 'C:\' || $$;$$;
+  RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+SELECT 'C:\', E'\';', $1;`,
+			expect: []string{
+				"CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  NEW.s := 'C:\\' || $$;$$;\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql",
+				"SELECT 'C:\\', E'\\';', $1",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if actual := splitSQL(c.syn, c.script); !reflect.DeepEqual(actual, c.expect) {
+			t.Errorf("%s: expected %q, got %q", c.msg, c.expect, actual)
+		}
+	}
+}
+
+// TestMySQL checks locking and history with live MySQL. Migrations do not use
+// DDL, since MySQL commits it implicitly.
+func TestMySQL(t *testing.T) {
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		t.Fatal("You must provide MYSQL_DSN environment variable to run test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("cannot open db: %s", err)
+	}
+	defer db.Close()
+
+	const table = "sdm_test_migrations"
+	drop := func() {
+		db.Exec("DROP TABLE IF EXISTS `" + table + "`, `" + table + "_lock`")
+	}
+	drop()
+	defer drop()
+
+	mig := func(ver int64, fail bool) Migration {
+		return Migration{
+			Version: ver,
+			Name:    fmt.Sprintf("m%d", ver),
+			Up: func(tx *sdm.Tx) error {
+				if fail {
+					return errors.New("failed")
+				}
+				_, err := tx.Exec(`SELECT ?`, ver)
+				return err
+			},
+			Down: func(tx *sdm.Tx) error {
+				_, err := tx.Exec(`SELECT ?`, ver)
+				return err
+			},
+		}
+	}
+	r := NewWithTable(sdm.New(db, "mysql"), table, mig(1, false), mig(2, false))
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatalf("cannot migrate: %s", err)
+	}
+	if e := []int64{1, 2}; !reflect.DeepEqual(applied, e) {
+		t.Errorf("expected %v applied, got %v", e, applied)
+	}
+	if e := []int64{1, 2}; !reflect.DeepEqual(versions(t, r), e) {
+		t.Errorf("expected %v in history, got %v", e, versions(t, r))
+	}
+
+	if _, err := db.Exec("INSERT INTO `" + table + "_lock` (id, locked_at) VALUES (1, NOW())"); err != nil {
+		t.Fatalf("cannot take lock: %s", err)
+	}
+	if _, err := r.Down(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if err := r.Unlock(); err != nil {
+		t.Fatalf("cannot unlock: %s", err)
+	}
+
+	if ver, err := r.Down(); err != nil || ver != 2 {
+		t.Fatalf("expected 2 reverted, got %d, %v", ver, err)
+	}
+	if e := []int64{1}; !reflect.DeepEqual(versions(t, r), e) {
+		t.Errorf("expected %v in history, got %v", e, versions(t, r))
+	}
+
+	r.Add(mig(3, true))
+	if _, err := r.Up(); err == nil || errors.Is(err, ErrLocked) {
+		t.Fatalf("expected migration 3 fails, got %v", err)
+	}
+	if e := []int64{1, 2}; !reflect.DeepEqual(versions(t, r), e) {
+		t.Errorf("expected %v in history, got %v", e, versions(t, r))
+	}
+	// lock must be released
+	if _, err := r.Up(); err == nil || errors.Is(err, ErrLocked) {
+		t.Errorf("expected migration 3 fails again, got %v", err)
+	}
+}
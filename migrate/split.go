@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// routineRe matches beginning of statements which might contain BEGIN ... END
+// blocks, like CREATE TRIGGER in sqlite and CREATE PROCEDURE in MySQL
+var routineRe = regexp.MustCompile(
+	`(?is)^\s*(?:(?:--[^\n]*\n|/\*.*?\*/)\s*)*CREATE\s+(?:OR\s+REPLACE\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:TEMP\s+|TEMPORARY\s+)?(?:TRIGGER|PROCEDURE|FUNCTION|EVENT)\b`,
+)
+
+// SplitSQL splits SQL script into statements by semicolon, with lexical rules
+// of driver.DefaultSyntax. Semicolons in quoted strings, quoted identifiers and
+// comments are ignored. Empty statements are removed.
+//
+// Semicolons in BEGIN ... END blocks of CREATE TRIGGER, PROCEDURE, FUNCTION
+// and EVENT are also ignored. Other compound statements are not supported, use
+// SQLRaw for them.
+func SplitSQL(script string) []string {
+	return splitSQL(driver.DefaultSyntax, script)
+}
+
+func splitSQL(syn driver.Syntax, script string) (ret []string) {
+	var (
+		last  int // start of current statement
+		depth int // depth of BEGIN ... END block
+	)
+	flush := func(end int) {
+		if s := strings.TrimSpace(script[last:end]); s != "" {
+			ret = append(ret, s)
+		}
+		last, depth = end+1, 0
+	}
+
+	syn.Scan(script, func(x int) int {
+		if script[x] == ';' {
+			if depth == 0 {
+				flush(x)
+			}
+			return 1
+		}
+		if x > 0 && driver.IsIdentByte(script[x-1]) {
+			return 1
+		}
+
+		w := word(script[x:])
+		switch strings.ToUpper(w) {
+		case "BEGIN":
+			if depth > 0 || routineRe.MatchString(script[last:x]) {
+				depth++
+			}
+		case "CASE":
+			if depth > 0 {
+				depth++
+			}
+		case "END":
+			// END IF, END LOOP... close blocks not counted
+			switch strings.ToUpper(word(strings.TrimLeft(script[x+len(w):], " \t\r\n"))) {
+			case "IF", "LOOP", "WHILE", "REPEAT":
+			default:
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+		return len(w)
+	})
+	flush(len(script))
+
+	return
+}
+
+// word returns leading identifier or keyword of str
+func word(str string) string {
+	x := 0
+	for x < len(str) && driver.IsIdentByte(str[x]) {
+		x++
+	}
+	return str[:x]
+}
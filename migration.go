@@ -0,0 +1,232 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// Migration is a list of DDL statements planned by Manager.PlanMigration.
+//
+// Review the statements before calling Apply, some of them might drop columns
+// or rebuild tables.
+type Migration struct {
+	Stmts []string
+	db    *sql.DB
+}
+
+// String returns statements as a SQL script
+func (m *Migration) String() string {
+	if len(m.Stmts) == 0 {
+		return ""
+	}
+	return strings.Join(m.Stmts, ";\n") + ";"
+}
+
+// Apply executes the statements in order, breaks at first error.
+func (m *Migration) Apply() error {
+	return m.ApplyContext(context.Background())
+}
+
+// ApplyContext is context-aware version of Apply
+func (m *Migration) ApplyContext(ctx context.Context) error {
+	for _, s := range m.Stmts {
+		if _, err := m.db.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("sdm: cannot apply %q: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// migrationPlan groups statements by phase, so foreign keys are dropped first,
+// and added after referenced tables and columns are ready.
+type migrationPlan struct {
+	rename []string // rename tables
+	drop   []string // drop indexes
+	create []string // create tables
+	alter  []string // add, modify or drop columns, and rebuild tables
+	index  []string // add indexes
+	fk     []string // add foreign keys
+}
+
+func (p *migrationPlan) stmts() []string {
+	ret := make([]string, 0, len(p.rename)+len(p.drop)+len(p.create)+len(p.alter)+len(p.index)+len(p.fk))
+	ret = append(ret, p.rename...)
+	ret = append(ret, p.drop...)
+	ret = append(ret, p.create...)
+	ret = append(ret, p.alter...)
+	ret = append(ret, p.index...)
+	return append(ret, p.fk...)
+}
+
+// PlanMigration generates DDL statements to migrate tables in database to
+// registered types, based on the result of Verify. Tables not registered are
+// left untouched.
+//
+// It returns ErrNoInspector if the driver does not implement driver.Inspector,
+// and ErrNoMigrator for driver.Migrator. Nothing is executed until
+// Migration.Apply is called:
+//
+//     mig, err := m.PlanMigration()
+//     if err != nil {
+//         return err
+//     }
+//     log.Print(mig) // review it
+//     err = mig.Apply()
+//
+// Changes not supported by ALTER TABLE (most of them in sqlite) are done by
+// rebuilding the table: create a new one, copy data, drop the old one and
+// rename. For sqlite, disable foreign key constraints before applying.
+// ErrNoRebuilder is returned if the driver does not implement driver.Rebuilder
+// in that case.
+//
+// Tables and columns are renamed instead of recreated if previous names are
+// specified by RegisterWas and "was=" option.
+//
+// NOT NULL column without default value cannot be added to a table containing
+// data, specify one with "default=" option.
+func (m *Manager) PlanMigration() (ret *Migration, err error) {
+	insp, ok := m.drv.(driver.Inspector)
+	if !ok {
+		return nil, ErrNoInspector
+	}
+	mig, ok := m.drv.(driver.Migrator)
+	if !ok {
+		return nil, ErrNoMigrator
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	live, err := m.inspect(insp)
+	if err != nil {
+		return
+	}
+
+	plan := &migrationPlan{}
+	for _, info := range m.sortedInfo() {
+		d, err := live.diff(info)
+		if err != nil {
+			return nil, err
+		}
+		if d.Missing {
+			plan.create = append(plan.create, mig.CreateTableSQL(
+				info.Table, info.Type, info.Fields, info.Indexes,
+			))
+			continue
+		}
+		if d.Empty() {
+			continue
+		}
+
+		table, cols := renameStmts(mig, info, d)
+		plan.rename = append(plan.rename, table...)
+		plan.alter = append(plan.alter, cols...)
+
+		p, ok := planTable(mig, info, d)
+		if !ok {
+			rb, ok := mig.(driver.Rebuilder)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrNoRebuilder, info.Table)
+			}
+			plan.alter = append(plan.alter, rebuildTable(rb, info, d)...)
+			continue
+		}
+		plan.drop = append(plan.drop, p.drop...)
+		plan.alter = append(plan.alter, p.alter...)
+		plan.index = append(plan.index, p.index...)
+		plan.fk = append(plan.fk, p.fk...)
+	}
+
+	return &Migration{Stmts: plan.stmts(), db: live.db}, nil
+}
+
+// renameStmts generates statements renaming the table and columns
+func renameStmts(mig driver.Migrator, info *tableInfo, d TableDiff) (table, cols []string) {
+	if d.RenamedFrom != "" {
+		table = mig.RenameTable(d.RenamedFrom, info.Table)
+	}
+	for _, c := range d.RenamedColumns {
+		cols = append(cols, mig.RenameColumn(
+			info.Table, c.From, info.Type, info.Defs[c.To], info.Indexes,
+		)...)
+	}
+	return
+}
+
+// planTable generates statements altering the table, ok is false if the table
+// has to be rebuilt
+func planTable(mig driver.Migrator, info *tableInfo, d TableDiff) (p migrationPlan, ok bool) {
+	add := func(dst *[]string, stmts []string, ok bool) bool {
+		*dst = append(*dst, stmts...)
+		return ok
+	}
+
+	// foreign keys first, as indexes might be used by them
+	for _, i := range d.ExtraIndexes {
+		if i.Type != driver.IndexTypeForeign {
+			continue
+		}
+		if s, ok := mig.DropIndex(info.Table, i); !add(&p.drop, s, ok) {
+			return p, false
+		}
+	}
+	for _, i := range d.ExtraIndexes {
+		if i.Type == driver.IndexTypeForeign {
+			continue
+		}
+		if s, ok := mig.DropIndex(info.Table, i); !add(&p.drop, s, ok) {
+			return p, false
+		}
+	}
+
+	for _, c := range d.MissingColumns {
+		s, ok := mig.AddColumn(info.Table, info.Type, info.Defs[c], info.Indexes)
+		if !add(&p.alter, s, ok) {
+			return p, false
+		}
+	}
+	for _, c := range d.Columns {
+		s, ok := mig.ModifyColumn(info.Table, info.Type, info.Defs[c.Expect.Name], info.Indexes)
+		if !add(&p.alter, s, ok) {
+			return p, false
+		}
+	}
+	for _, c := range d.ExtraColumns {
+		if s, ok := mig.DropColumn(info.Table, c); !add(&p.alter, s, ok) {
+			return p, false
+		}
+	}
+
+	for _, i := range d.MissingIndexes {
+		dst := &p.index
+		if i.Type == driver.IndexTypeForeign {
+			dst = &p.fk
+		}
+		if s, ok := mig.AddIndex(info.Table, info.Type, info.Fields, i); !add(dst, s, ok) {
+			return p, false
+		}
+	}
+
+	return p, true
+}
+
+// rebuildTable generates statements rebuilding the table, keeping data of
+// columns existing in both old and new table
+func rebuildTable(rb driver.Rebuilder, info *tableInfo, d TableDiff) []string {
+	missing := map[string]bool{}
+	for _, c := range d.MissingColumns {
+		missing[c] = true
+	}
+	keep := []string{}
+	for _, c := range info.Fields {
+		if !missing[c.Name] {
+			keep = append(keep, c.Name)
+		}
+	}
+
+	return rb.RebuildTable(info.Table, info.Type, info.Fields, info.Indexes, keep)
+}
@@ -0,0 +1,251 @@
+package sdm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+type testmigrate struct {
+	ID   int     `sdm:"id,ai"`
+	Name string  `sdm:"name,uniq_migrate_name"`
+	Memo *string `sdm:"memo"`
+}
+
+func TestPlanMigrationAlter(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3")
+	m.Reg(testmigrate{}, testTable1{})
+
+	_, err := db.Exec(`CREATE TABLE "testmigrate" ("id" INTEGER NOT NULL CONSTRAINT "p" PRIMARY KEY AUTOINCREMENT,"name" TEXT NOT NULL)`)
+	if err != nil {
+		t.Fatalf("cannot create table: %s", err)
+	}
+
+	mig, err := m.PlanMigration()
+	if err != nil {
+		t.Fatalf("cannot plan migration: %s", err)
+	}
+	expect := []string{
+		`CREATE TABLE 'testtable1' ("a" INTEGER NOT NULL)`,
+		`ALTER TABLE "testmigrate" ADD COLUMN "memo" TEXT`,
+		`CREATE UNIQUE INDEX "migrate_name" ON "testmigrate" ("name")`,
+	}
+	if !reflect.DeepEqual(mig.Stmts, expect) {
+		t.Fatalf("expected %v, got %v", expect, mig.Stmts)
+	}
+
+	if err := mig.Apply(); err != nil {
+		t.Fatalf("cannot apply migration: %s", err)
+	}
+	diff, err := m.Verify()
+	if err != nil {
+		t.Fatalf("cannot verify: %s", err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected no difference after migration, got\n%s", diff)
+	}
+
+	mig, err = m.PlanMigration()
+	if err != nil {
+		t.Fatalf("cannot plan migration again: %s", err)
+	}
+	if len(mig.Stmts) != 0 {
+		t.Errorf("expected nothing to migrate, got %v", mig.Stmts)
+	}
+}
+
+// norebuildDriver hides driver.Rebuilder of sqlite driver
+type norebuildDriver struct {
+	driver.Driver
+	driver.Inspector
+	driver.Migrator
+}
+
+func init() {
+	driver.RegisterDriver("sqlite3norebuild", func(p map[string]string) driver.Driver {
+		d := driver.GetDriver("sqlite3")
+		return norebuildDriver{d, d.(driver.Inspector), d.(driver.Migrator)}
+	})
+}
+
+func TestPlanMigrationNoRebuilder(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3norebuild")
+	m.Reg(testmigrate{})
+
+	if _, err := db.Exec(`CREATE TABLE "testmigrate" ("id" INTEGER NOT NULL CONSTRAINT "p" PRIMARY KEY AUTOINCREMENT,"name" VARCHAR(5) NOT NULL,"extra" TEXT)`); err != nil {
+		t.Fatalf("cannot prepare table: %s", err)
+	}
+	if _, err := m.PlanMigration(); !errors.Is(err, ErrNoRebuilder) {
+		t.Errorf("expected ErrNoRebuilder, got %v", err)
+	}
+}
+
+func TestPlanMigrationNoInspector(t *testing.T) {
+	driver.MySQLStub()
+	m := New(nil, "mysqlstub")
+	if _, err := m.PlanMigration(); !errors.Is(err, ErrNoInspector) {
+		t.Errorf("expected ErrNoInspector, got %v", err)
+	}
+}
+
+func TestPlanMigrationRebuild(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3")
+	m.Reg(testmigrate{})
+
+	schema := []string{
+		`CREATE TABLE "testmigrate" ("id" INTEGER NOT NULL CONSTRAINT "p" PRIMARY KEY AUTOINCREMENT,"name" VARCHAR(5) NOT NULL,"extra" TEXT)`,
+		`INSERT INTO "testmigrate" ("name","extra") VALUES ('john','x')`,
+	}
+	for _, s := range schema {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("cannot prepare table: %s", err)
+		}
+	}
+
+	mig, err := m.PlanMigration()
+	if err != nil {
+		t.Fatalf("cannot plan migration: %s", err)
+	}
+	expect := []string{
+		`CREATE TABLE '_sdm_testmigrate' ("id" INTEGER NOT NULL CONSTRAINT "testmigrate_pk" PRIMARY KEY AUTOINCREMENT,"name" TEXT NOT NULL,"memo" TEXT,CONSTRAINT "migrate_name" UNIQUE ("name"))`,
+		`INSERT INTO "_sdm_testmigrate" ("id","name") SELECT "id","name" FROM "testmigrate"`,
+		`DROP TABLE "testmigrate"`,
+		`ALTER TABLE "_sdm_testmigrate" RENAME TO "testmigrate"`,
+	}
+	if !reflect.DeepEqual(mig.Stmts, expect) {
+		t.Fatalf("expected %v, got %v", expect, mig.Stmts)
+	}
+
+	if err := mig.Apply(); err != nil {
+		t.Fatalf("cannot apply migration: %s", err)
+	}
+	diff, err := m.Verify()
+	if err != nil {
+		t.Fatalf("cannot verify: %s", err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected no difference after migration, got\n%s", diff)
+	}
+
+	var data testmigrate
+	rows := m.Find(testmigrate{}, "")
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatalf("cannot read migrated data: %s", rows.Err())
+	}
+	if err := rows.Scan(&data); err != nil {
+		t.Fatalf("cannot scan migrated data: %s", err)
+	}
+	if data.ID != 1 || data.Name != "john" || data.Memo != nil {
+		t.Errorf("unexpected migrated data: %+v", data)
+	}
+}
+
+type testrenameaddr struct {
+	City string `sdm:"city,was=town"`
+}
+
+type testrename struct {
+	ID   int            `sdm:"id,ai"`
+	Name string         `sdm:"name,was=title,uniq_rename_name"`
+	Addr testrenameaddr `sdm:"addr_,inline"`
+}
+
+func prepareRename(t *testing.T) (*Manager, *sql.DB) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3")
+	m.RegisterWas(testrename{}, "testrename", "oldrename")
+
+	schema := []string{
+		`CREATE TABLE "oldrename" ("id" INTEGER NOT NULL CONSTRAINT "p" PRIMARY KEY AUTOINCREMENT,"title" TEXT NOT NULL,"addr_town" TEXT NOT NULL,CONSTRAINT "rename_name" UNIQUE ("title"))`,
+		`INSERT INTO "oldrename" ("title","addr_town") VALUES ('john','taipei')`,
+	}
+	for _, s := range schema {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("cannot prepare table: %s", err)
+		}
+	}
+	return m, db
+}
+
+func checkRenamed(t *testing.T, m *Manager) {
+	diff, err := m.Verify()
+	if err != nil {
+		t.Fatalf("cannot verify: %s", err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected no difference after renaming, got\n%s", diff)
+	}
+
+	var data testrename
+	rows := m.Find(testrename{}, "")
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatalf("cannot read renamed data: %s", rows.Err())
+	}
+	if err := rows.Scan(&data); err != nil {
+		t.Fatalf("cannot scan renamed data: %s", err)
+	}
+	if data.Name != "john" || data.Addr.City != "taipei" {
+		t.Errorf("unexpected renamed data: %+v", data)
+	}
+}
+
+func TestPlanMigrationRename(t *testing.T) {
+	m, _ := prepareRename(t)
+
+	diff, err := m.Verify()
+	if err != nil {
+		t.Fatalf("cannot verify: %s", err)
+	}
+	expect := SchemaDiff{{
+		Type:        reflect.TypeOf(testrename{}),
+		Table:       "testrename",
+		RenamedFrom: "oldrename",
+		RenamedColumns: []ColumnRename{
+			{From: "title", To: "name"},
+			{From: "addr_town", To: "addr_city"},
+		},
+	}}
+	if !reflect.DeepEqual(diff, expect) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expect, diff)
+	}
+
+	mig, err := m.PlanMigration()
+	if err != nil {
+		t.Fatalf("cannot plan migration: %s", err)
+	}
+	stmts := []string{
+		`ALTER TABLE "oldrename" RENAME TO "testrename"`,
+		`ALTER TABLE "testrename" RENAME COLUMN "title" TO "name"`,
+		`ALTER TABLE "testrename" RENAME COLUMN "addr_town" TO "addr_city"`,
+	}
+	if !reflect.DeepEqual(mig.Stmts, stmts) {
+		t.Fatalf("expected %v, got %v", stmts, mig.Stmts)
+	}
+
+	if err := mig.Apply(); err != nil {
+		t.Fatalf("cannot apply migration: %s", err)
+	}
+	checkRenamed(t, m)
+}
+
+func TestCreateTablesNotExistRename(t *testing.T) {
+	m, _ := prepareRename(t)
+	m.Reg(testTable1{})
+
+	if err := m.CreateTablesNotExist(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	checkRenamed(t, m)
+}
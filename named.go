@@ -0,0 +1,166 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// Named converts SQL with named parameters like ":col" into positional ones,
+// returns converted SQL and the arguments.
+//
+// Values are taken from arg, which is a registered struct (or pointer to it)
+// resolving names by column name, or a map[string]interface{}. Values are
+// converted by driver like other statements generated by SDM. ErrUnknownColumn
+// is returned if some name is not a column of the struct, and ErrUnknownParam
+// if it is not found in the map.
+//
+// Names in string literals, quoted identifiers and comments are skipped, so are
+// type casts of PostgreSQL like "::text". Use it with Query if you need a map:
+//
+//     qstr, args, err := m.Named(`SELECT %cols% FROM %table% WHERE name=:name`, map[string]interface{}{
+//         "name": "John",
+//     })
+//     rows := m.Query(User{}, qstr, args...)
+func (m *Manager) Named(qstr string, arg interface{}) (string, []interface{}, error) {
+	lookup, err := m.namedArgs(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := m.Placeholders()
+	var vals []interface{}
+	qstr = rewriteSQL(driver.SyntaxOf(m.drv), qstr, func(rest string) (string, int) {
+		if rest[0] != ':' || err != nil {
+			return "", 0
+		}
+		if len(rest) > 1 && rest[1] == ':' {
+			return "::", 2
+		}
+
+		n := 1
+		for n < len(rest) && isNameByte(rest[n], n > 1) {
+			n++
+		}
+		if n == 1 {
+			return "", 0
+		}
+
+		name := rest[1:n]
+		val, typ, e := lookup(name)
+		if e != nil {
+			err = fmt.Errorf("%w: %s", e, name)
+			return "", 0
+		}
+		vals = append(vals, val)
+		return p.Next(typ), n
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return qstr, vals, nil
+}
+
+// isNameByte reports whether c can be used in parameter name, digits are not
+// allowed at first byte
+func isNameByte(c byte, digit bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return true
+	case c >= '0' && c <= '9':
+		return digit
+	}
+	return false
+}
+
+// namedArgs creates a function resolving named parameter from arg
+func (m *Manager) namedArgs(arg interface{}) (func(name string) (val interface{}, typ reflect.Type, err error), error) {
+	if vals, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, reflect.Type, error) {
+			val, ok := vals[name]
+			if !ok {
+				return nil, nil, ErrUnknownParam
+			}
+			if val == nil {
+				return nil, nil, nil
+			}
+			v := reflect.ValueOf(val)
+			if vsql, ok := m.drv.GetValuer(v); ok {
+				return vsql, v.Type(), nil
+			}
+			return val, v.Type(), nil
+		}, nil
+	}
+
+	v := reflect.ValueOf(arg)
+	if !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, errors.New("sdm: cannot take named parameters from nil")
+	}
+	info, err := m.lookupInfoOf(arg)
+	if err != nil {
+		return nil, err
+	}
+	v = reflect.Indirect(v)
+	return func(name string) (interface{}, reflect.Type, error) {
+		f, ok := info.Defs[name]
+		if !ok {
+			return nil, nil, ErrUnknownColumn
+		}
+		return m.fieldVal(v, f), info.Type.FieldByIndex(f.Index).Type, nil
+	}, nil
+}
+
+// NamedQuery is like Query, but parameters are named like ":col" and taken from
+// data, which is also the type of rows. See Named for detail.
+// It panics if type is not registered and auto register is not enabled.
+//
+//     filter := Member{GroupID: 1, CD: 3}
+//     rows := m.NamedQuery(&filter, `SELECT %cols% FROM %table% WHERE group_id=:group_id AND cd>:cd`)
+func (m *Manager) NamedQuery(data interface{}, qstr string) *Rows {
+	return m.NamedQueryContext(context.Background(), data, qstr)
+}
+
+// NamedQueryContext is context-aware version of NamedQuery
+func (m *Manager) NamedQueryContext(ctx context.Context, data interface{}, qstr string) *Rows {
+	return m.namedQuery(ctx, m.db, data, qstr)
+}
+
+func (m *Manager) namedQuery(ctx context.Context, c conn, data interface{}, qstr string) *Rows {
+	qstr, args, err := m.Named(qstr, data)
+	if err != nil {
+		t := reflect.TypeOf(data)
+		if t == nil {
+			// untyped nil
+			return &Rows{e: err}
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return m.createErrorRow(t, err)
+	}
+	return m.query(ctx, c, data, qstr, args)
+}
+
+// NamedExec is like Exec, but parameters are named like ":col" and taken from
+// arg. See Named for detail.
+func (m *Manager) NamedExec(qstr string, arg interface{}) (sql.Result, error) {
+	return m.NamedExecContext(context.Background(), qstr, arg)
+}
+
+// NamedExecContext is context-aware version of NamedExec
+func (m *Manager) NamedExecContext(ctx context.Context, qstr string, arg interface{}) (sql.Result, error) {
+	return m.namedExec(ctx, m.db, qstr, arg)
+}
+
+func (m *Manager) namedExec(ctx context.Context, c conn, qstr string, arg interface{}) (sql.Result, error) {
+	qstr, args, err := m.Named(qstr, arg)
+	if err != nil {
+		return nil, err
+	}
+	return c.ExecContext(ctx, qstr, args...)
+}
@@ -0,0 +1,163 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	m := initverdb(t)
+	data := testver{ID: 1, Name: "a", Ver: 2}
+	cases := []struct {
+		qstr   string
+		arg    interface{}
+		expect string
+		vals   []interface{}
+		msg    string
+	}{
+		{
+			qstr:   `id=:id AND name=:name`,
+			arg:    data,
+			expect: `id=? AND name=?`,
+			vals:   []interface{}{1, "a"},
+			msg:    "struct",
+		},
+		{
+			qstr:   `version>:version OR version<:version`,
+			arg:    &data,
+			expect: `version>? OR version<?`,
+			vals:   []interface{}{2, 2},
+			msg:    "pointer to struct, repeated name",
+		},
+		{
+			qstr:   `name=:name AND id IS NOT :id`,
+			arg:    map[string]interface{}{"name": "b", "id": nil},
+			expect: `name=? AND id IS NOT ?`,
+			vals:   []interface{}{"b", nil},
+			msg:    "map",
+		},
+		{
+			qstr:   `name=':name' AND ":name"=:name -- :name` + "\n" + `/* :name */`,
+			arg:    data,
+			expect: `name=':name' AND ":name"=? -- :name` + "\n" + `/* :name */`,
+			vals:   []interface{}{"a"},
+			msg:    "literals and comments",
+		},
+		{
+			qstr:   `name::text=:name AND t='10:30' AND a[1:2]=:id`,
+			arg:    data,
+			expect: `name::text=? AND t='10:30' AND a[1:2]=?`,
+			vals:   []interface{}{"a", 1},
+			msg:    "not a name",
+		},
+		{
+			qstr:   `name='C:\' AND id=:id`,
+			arg:    data,
+			expect: `name='C:\' AND id=?`,
+			vals:   []interface{}{1},
+			msg:    "backslash in sqlite",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			qstr, vals, err := m.Named(c.qstr, c.arg)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if qstr != c.expect {
+				t.Errorf("expected %s, got %s", c.expect, qstr)
+			}
+			if !reflect.DeepEqual(vals, c.vals) {
+				t.Errorf("expected values %v, got %v", c.vals, vals)
+			}
+		})
+	}
+
+	if _, _, err := m.Named(`id=:nope`, data); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+	if _, _, err := m.Named(`id=:nope`, map[string]interface{}{}); !errors.Is(err, ErrUnknownParam) {
+		t.Errorf("expected ErrUnknownParam for map, got %v", err)
+	}
+	if _, _, err := m.Named(`id=:id`, (*testver)(nil)); err == nil {
+		t.Error("expected error for nil pointer")
+	}
+	if err := m.NamedQuery((*testver)(nil), `SELECT %cols% FROM %table% WHERE id=:id`).Err(); err == nil {
+		t.Error("expected error when querying with nil pointer")
+	}
+	if err := m.NamedQuery(nil, `SELECT %cols% FROM %table% WHERE id=:id`).Err(); err == nil {
+		t.Error("expected error when querying with nil")
+	}
+	var e *ErrNotRegistered
+	if _, _, err := m.Named(`id=:id`, testai{}); !errors.As(err, &e) {
+		t.Errorf("expected ErrNotRegistered, got %v", err)
+	}
+}
+
+func TestNamedNumbered(t *testing.T) {
+	m := initbinderdb(t)
+	qstr, _, err := m.Named(`name=:name AND (id=:id OR id>:id)`, testver{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expect := `name=?1 AND (id=?2 OR id>?3)`; qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+}
+
+func TestNamedQueryExec(t *testing.T) {
+	m := initverdb(t)
+	for _, n := range []string{"a", "b", "c"} {
+		if _, err := m.NamedExec(`INSERT INTO testver (name, version) VALUES (:name, :version)`, testver{Name: n, Ver: 1}); err != nil {
+			t.Fatalf("cannot insert %s: %s", n, err)
+		}
+	}
+	if _, err := m.NamedExec(`UPDATE testver SET version=:ver WHERE name=:name`, map[string]interface{}{
+		"ver":  2,
+		"name": "c",
+	}); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+
+	filter := testver{ID: 1, Ver: 1}
+	rows := m.NamedQuery(&filter, `SELECT %cols% FROM %table% WHERE id>:id AND version=:version`)
+	var names []string
+	for rows.Next() {
+		var x testver
+		if err := rows.Scan(&x); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		names = append(names, x.Name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		t.Fatalf("cannot query: %s", err)
+	}
+	if !reflect.DeepEqual(names, []string{"b"}) {
+		t.Errorf("expected only b is found, got %v", names)
+	}
+
+	if rows := m.NamedQuery(&filter, `SELECT %cols% FROM %table% WHERE id=:nope`); !errors.Is(rows.Err(), ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", rows.Err())
+	}
+
+	tx, err := m.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.NamedExec(`DELETE FROM testver WHERE name=:name`, testver{Name: "a"}); err != nil {
+		t.Fatalf("cannot delete in tx: %s", err)
+	}
+	var actual testver
+	rows = tx.NamedQuery(&testver{}, `SELECT %cols% FROM %table% ORDER BY id LIMIT 1`)
+	if rows.Next() {
+		rows.Scan(&actual)
+	}
+	rows.Close()
+	if actual.Name != "b" {
+		t.Errorf("expected a is deleted in tx, got %+v", actual)
+	}
+}
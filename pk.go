@@ -0,0 +1,234 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// pkCond generates WHERE clause matching primary key of data
+func (m *Manager) pkCond(info *tableInfo, v reflect.Value, p *Placeholders) (cond string, vals []interface{}, err error) {
+	if info.PKIndex < 0 {
+		return "", nil, ErrNoPrimaryKey
+	}
+
+	cols := info.Indexes[info.PKIndex].Cols
+	vals = make([]interface{}, len(cols))
+	for x, c := range cols {
+		vals[x] = m.fieldVal(v, info.Defs[c])
+	}
+
+	return m.pkWhere(info, p), vals, nil
+}
+
+// pkWhere generates WHERE clause with placeholders of primary key columns
+func (m *Manager) pkWhere(info *tableInfo, p *Placeholders) string {
+	cols := info.Indexes[info.PKIndex].Cols
+	conds := make([]string, len(cols))
+	for x, c := range cols {
+		f := info.Defs[c]
+		conds[x] = m.drv.Col(info.Table, c, driver.QWhere) + `=` +
+			p.Next(info.Type.FieldByIndex(f.Index).Type)
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+func (m *Manager) makeUpdateByPK(data interface{}) (qstr string, vals []interface{}, err error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	info, err := m.lookupInfo(v.Type())
+	if err != nil {
+		return
+	}
+
+	// used as where of makeUpdateSQL, numbered from 1
+	cond, condVals, err := m.pkCond(info, v, m.Placeholders())
+	if err != nil {
+		return
+	}
+	pk := info.Indexes[info.PKIndex]
+
+	cols := make([]string, 0, len(info.Fields))
+	for _, f := range info.Fields {
+		if f.AI || pk.HasCol(f.Name) || f.Name == info.Created {
+			continue
+		}
+		cols = append(cols, f.Name)
+	}
+
+	return m.makeUpdateSQL(info, v, cols, true, cond, condVals)
+}
+
+func (m *Manager) makeDeleteByPK(data interface{}) (qstr string, vals []interface{}, err error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	info, err := m.lookupInfo(v.Type())
+	if err != nil {
+		return
+	}
+
+	cond, vals, err := m.pkCond(info, v, m.Placeholders())
+	if err != nil {
+		return
+	}
+
+	qstr = `DELETE FROM ` + m.drv.Quote(info.Table) + ` WHERE ` + cond
+	return
+}
+
+// UpdateByPK updates data in db, using primary key (can be composite) as condition.
+//
+// ErrNoPrimaryKey is returned if type has no primary key. Version column is
+// handled like Update.
+func (m *Manager) UpdateByPK(data interface{}) (sql.Result, error) {
+	return m.UpdateByPKContext(context.Background(), data)
+}
+
+// UpdateByPKContext is context-aware version of UpdateByPK
+func (m *Manager) UpdateByPKContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.updateByPK(ctx, m.db, data)
+}
+
+func (m *Manager) updateByPK(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
+	}
+	stamped := m.stamp(info, data, m.now(), false)
+	qstr, vals, err := m.makeUpdateByPK(stamped)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.ExecContext(ctx, qstr, vals...)
+	if res, err = m.checkVer(info, data, res, err); err == nil {
+		m.writeBack(info, data, stamped, info.Updated)
+	}
+	return res, err
+}
+
+// DeleteByPK deletes data in db, using primary key (can be composite) as condition.
+//
+// ErrNoPrimaryKey is returned if type has no primary key, and ErrNoRowsAffected
+// is returned if nothing is deleted and StrictDelete is set. Soft delete is
+// handled like Delete.
+func (m *Manager) DeleteByPK(data interface{}) (sql.Result, error) {
+	return m.DeleteByPKContext(context.Background(), data)
+}
+
+// DeleteByPKContext is context-aware version of DeleteByPK
+func (m *Manager) DeleteByPKContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.deleteByPK(ctx, m.db, data)
+}
+
+func (m *Manager) deleteByPK(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	info, err := m.lookupInfoOf(data)
+	if err != nil {
+		return nil, err
+	}
+	if info.SoftDel == "" {
+		qstr, vals, err := m.makeDeleteByPK(data)
+		if err != nil {
+			return nil, err
+		}
+		return m.checkAffected(c.ExecContext(ctx, qstr, vals...))
+	}
+
+	now := m.now()
+	qstr, vals, err := m.makeSoftDeleteByPK(data, now)
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.checkAffected(c.ExecContext(ctx, qstr, vals...))
+	if err == nil {
+		m.markDeleted(info, data, now)
+	}
+	return res, err
+}
+
+// Save inserts data if auto increment column is zero, or updates it by primary key.
+//
+// ErrNoAutoIncrement is returned if type has no auto increment column. Pass
+// pointer to get auto increment column filled, see Insert for detail.
+func (m *Manager) Save(data interface{}) (sql.Result, error) {
+	return m.SaveContext(context.Background(), data)
+}
+
+// SaveContext is context-aware version of Save
+func (m *Manager) SaveContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.save(ctx, m.db, data)
+}
+
+func (m *Manager) save(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	info, err := m.lookupInfo(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range info.Fields {
+		if !f.AI {
+			continue
+		}
+
+		if vf, ok := fieldByIndex(v, f.Index, false); ok && !vf.IsZero() {
+			return m.updateByPK(ctx, c, data)
+		}
+		return m.insert(ctx, c, data)
+	}
+
+	return nil, ErrNoAutoIncrement
+}
+
+// returnedID is the sql.Result of INSERT statement with RETURNING clause
+type returnedID struct {
+	id    int64
+	found bool // false if no row is returned, like INSERT ... ON CONFLICT DO NOTHING
+}
+
+func (r returnedID) LastInsertId() (int64, error) {
+	if !r.found {
+		return 0, errors.New("sdm: no row is returned by RETURNING clause")
+	}
+	return r.id, nil
+}
+
+func (r returnedID) RowsAffected() (int64, error) {
+	if !r.found {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// execInsert executes INSERT statement, auto increment value is returned by
+// RETURNING clause if driver implements driver.Returner
+func (m *Manager) execInsert(ctx context.Context, c conn, info *tableInfo, qstr string, vals []interface{}) (sql.Result, error) {
+	ret, ok := m.drv.(driver.Returner)
+	ai := ""
+	for _, f := range info.Fields {
+		if f.AI {
+			ai = f.Name
+			break
+		}
+	}
+	if !ok || ai == "" {
+		return c.ExecContext(ctx, qstr, vals...)
+	}
+
+	rows, err := c.QueryContext(ctx, qstr+ret.Returning(ai), vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res returnedID
+	if res.found = rows.Next(); res.found {
+		err = rows.Scan(&res.id)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	return res, err
+}
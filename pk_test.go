@@ -0,0 +1,229 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+	"github.com/mattn/go-sqlite3"
+)
+
+type testCompositePK struct {
+	A     int      `sdm:"a,pri_pk"`
+	B     string   `sdm:"b,pri_pk"`
+	Score float64  `sdm:"score"`
+	Note  *string  `sdm:"note"`
+	Rate  *float64 `sdm:"rate"`
+}
+
+func initpkdb(t *testing.T) *Manager {
+	db := newdb(t)
+	m := New(db, "sqlite3")
+	m.Reg(testCompositePK{}, testai{}, testok{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	return m
+}
+
+func countRows(t *testing.T, m *Manager, table string) (cnt int) {
+	row := m.Connection().QueryRow(`SELECT COUNT(*) FROM ` + table)
+	if err := row.Scan(&cnt); err != nil {
+		t.Fatalf("cannot count %s: %s", table, err)
+	}
+	return
+}
+
+func TestUpdateByPK(t *testing.T) {
+	m := initpkdb(t)
+	data := testCompositePK{A: 1, B: "b", Score: 0.1}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+	if _, err := m.Insert(testCompositePK{A: 1, B: "c", Score: 0.1}); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	note := "updated"
+	data.Score = 0.3
+	data.Note = &note
+	res, err := m.UpdateByPK(data)
+	if err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("expected 1 row affected, got %d", n)
+	}
+
+	var actual testCompositePK
+	if err := m.QueryRow(&actual, `SELECT %cols% FROM %table% WHERE a=1 AND b='b'`); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual.Score != 0.3 || actual.Note == nil || *actual.Note != note {
+		t.Errorf("unexpected result: %+v", actual)
+	}
+
+	if _, err := m.UpdateByPK(testok{}); !errors.Is(err, ErrNoPrimaryKey) {
+		t.Errorf("expected ErrNoPrimaryKey, got %v", err)
+	}
+}
+
+func TestDeleteByPK(t *testing.T) {
+	m := initpkdb(t)
+	// float and NULL columns should not affect deleting
+	data := testCompositePK{A: 1, B: "b", Score: 0.1}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+	if _, err := m.Insert(testCompositePK{A: 2, B: "b"}); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	tx, err := m.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin transaction: %s", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.DeleteByPK(data)
+	if err != nil {
+		t.Fatalf("cannot delete: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("expected 1 row affected, got %d", n)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	if cnt := countRows(t, m, "testcompositepk"); cnt != 1 {
+		t.Errorf("expected 1 row left, got %d", cnt)
+	}
+}
+
+func TestSave(t *testing.T) {
+	m := initpkdb(t)
+	data := testai{ExportString: "insert"}
+	if _, err := m.Save(&data); err != nil {
+		t.Fatalf("cannot save new data: %s", err)
+	}
+	if data.ExportInt != 1 {
+		t.Fatalf("expected id to be filled back, got %d", data.ExportInt)
+	}
+
+	data.ExportString = "update"
+	if _, err := m.Save(&data); err != nil {
+		t.Fatalf("cannot save existing data: %s", err)
+	}
+	if cnt := countRows(t, m, "testai"); cnt != 1 {
+		t.Errorf("expected 1 row, got %d", cnt)
+	}
+
+	actual, err := LoadByPK[testai](m, 1)
+	if err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual.ExportString != "update" {
+		t.Errorf("expected estr to be updated, got %s", actual.ExportString)
+	}
+
+	if _, err := m.Save(testCompositePK{}); !errors.Is(err, ErrNoAutoIncrement) {
+		t.Errorf("expected ErrNoAutoIncrement, got %v", err)
+	}
+}
+
+// returningConn emulates RETURNING clause, which is not supported by bundled
+// sqlite, with last_insert_rowid()
+type returningConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c returningConn) QueryContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	idx := strings.Index(query, " RETURNING ")
+	if idx < 0 {
+		return c.SQLiteConn.QueryContext(ctx, query, args)
+	}
+	if _, err := c.SQLiteConn.ExecContext(ctx, query[:idx], args); err != nil {
+		return nil, err
+	}
+	return c.SQLiteConn.QueryContext(ctx, "SELECT last_insert_rowid()", nil)
+}
+
+type returningDriver struct{}
+
+func (returningDriver) Open(dsn string) (sqldriver.Conn, error) {
+	c, err := (&sqlite3.SQLiteDriver{}).Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return returningConn{c.(*sqlite3.SQLiteConn)}, nil
+}
+
+// returnerDriver is sqlite driver using RETURNING clause
+type returnerDriver struct {
+	driver.Driver
+	called *int
+}
+
+func (d returnerDriver) Returning(col string) string {
+	*d.called++
+	return " RETURNING " + d.Quote(col)
+}
+
+func TestInsertReturning(t *testing.T) {
+	called := 0
+	sql.Register("sqlite3returning", returningDriver{})
+	driver.RegisterDriver("sqlite3returning", func(p map[string]string) driver.Driver {
+		return returnerDriver{Driver: driver.GetDriver("sqlite3"), called: &called}
+	})
+	db, err := sql.Open("sqlite3returning", ":memory:")
+	if err != nil {
+		t.Fatalf("Cannot open sqlite connection: %s", err)
+	}
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3returning")
+	m.Reg(testai{}, testok{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	for x := 1; x <= 3; x++ {
+		data := testai{ExportString: "insert"}
+		res, err := m.Insert(&data)
+		if err != nil {
+			t.Fatalf("cannot insert #%d: %s", x, err)
+		}
+		if data.ExportInt != x {
+			t.Errorf("expected id %d to be filled back, got %d", x, data.ExportInt)
+		}
+		if id, _ := res.LastInsertId(); id != int64(x) {
+			t.Errorf("expected LastInsertId to be %d, got %d", x, id)
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			t.Errorf("expected 1 row affected, got %d", n)
+		}
+	}
+	if called != 3 {
+		t.Errorf("expected RETURNING clause to be used 3 times, got %d", called)
+	}
+
+	// types without auto increment column use normal INSERT
+	if _, err := m.Insert(testok{ExportInt: 1}); err != nil {
+		t.Fatalf("cannot insert testok: %s", err)
+	}
+	if called != 3 {
+		t.Errorf("expected RETURNING clause not to be used, got %d calls", called)
+	}
+
+	// nothing returned, like INSERT ... ON CONFLICT DO NOTHING
+	var none returnedID
+	if n, err := none.RowsAffected(); n != 0 || err != nil {
+		t.Errorf("expected no row affected, got %d, %v", n, err)
+	}
+	if _, err := none.LastInsertId(); err == nil {
+		t.Error("expected error when no id returned")
+	}
+}
@@ -0,0 +1,132 @@
+package sdm
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// Placeholders allocates placeholders for parameters of a statement in order.
+//
+// Drivers implementing driver.Binder get numbered placeholders like "$1", so
+// statements built from many pieces must share an allocator:
+//
+//     p := m.Placeholders()
+//     qstr := `SELECT %cols% FROM %table% WHERE name=` + p.Next(reflect.TypeOf("")) +
+//         ` AND id ` + p.In(ids)
+//     rows := m.Query(User{}, qstr, append([]interface{}{name}, AsArgs(ids)...)...)
+//
+// Other drivers get what driver.Driver.GetPlaceholder returns, mostly "?".
+type Placeholders struct {
+	drv  driver.Driver
+	bind driver.Binder // nil if driver uses unnumbered placeholders
+	n    int           // number of allocated placeholders
+}
+
+// Placeholders creates a placeholder allocator, starting from first parameter
+func (m *Manager) Placeholders() *Placeholders {
+	b, _ := m.drv.(driver.Binder)
+	return &Placeholders{drv: m.drv, bind: b}
+}
+
+// Numbered reports whether placeholders are numbered. Parameters must be
+// passed in order of allocation if true, or in order of appearance otherwise.
+func (p *Placeholders) Numbered() bool {
+	return p.bind != nil
+}
+
+// Len returns number of allocated placeholders
+func (p *Placeholders) Len() int {
+	return p.n
+}
+
+// Skip skips n placeholders, which is useful if first n parameters are used by
+// hand-written SQL.
+func (p *Placeholders) Skip(n int) *Placeholders {
+	p.n += n
+	return p
+}
+
+// Next allocates a placeholder for a parameter of typ
+func (p *Placeholders) Next(typ reflect.Type) string {
+	p.n++
+	if p.bind != nil {
+		return p.bind.Placeholder(p.n)
+	}
+	return p.drv.GetPlaceholder(typ)
+}
+
+// In generates SQL IN clause for elements of arr, panics if not
+// array/slice/map/chan
+func (p *Placeholders) In(arr interface{}) string {
+	v := reflect.ValueOf(arr)
+	sz := v.Len()
+	typ := v.Type().Elem()
+	holders := make([]string, sz)
+	for x := range holders {
+		holders[x] = p.Next(typ)
+	}
+	return `IN (` + strings.Join(holders, ",") + `)`
+}
+
+// Rebind rewrites "?" in qstr into placeholders allocated by p, so hand-written
+// SQL works with drivers using numbered placeholders. qstr is returned as is if
+// placeholders are not numbered.
+//
+// "?" in string literals, quoted identifiers and comments are skipped, so are
+// the ones followed by digits like "?1", which are numbered already. Literals
+// are recognized with lexical rules of the driver, see driver.SyntaxOf.
+//
+// JSONB operators "?|" and "?&" of PostgreSQL are kept, write "??" for the "?"
+// operator:
+//
+//     data ?? 'key' AND data ?| array['a', 'b'] AND id = ?
+func (p *Placeholders) Rebind(qstr string) string {
+	if !p.Numbered() || strings.IndexByte(qstr, '?') < 0 {
+		return qstr
+	}
+
+	return rewriteSQL(driver.SyntaxOf(p.drv), qstr, func(rest string) (string, int) {
+		if rest[0] != '?' {
+			return "", 0
+		}
+		if len(rest) > 1 {
+			switch c := rest[1]; {
+			case c == '?':
+				return "?", 2
+			case c == '|' || c == '&' || c >= '0' && c <= '9':
+				return "", 0
+			}
+		}
+		return p.Next(nil), 1
+	})
+}
+
+// rebind rewrites hand-written SQL if Rebind is enabled
+func (m *Manager) rebind(qstr string) string {
+	if !m.Rebind {
+		return qstr
+	}
+	return m.Placeholders().Rebind(qstr)
+}
+
+// rewriteSQL calls f with rest of qstr at each byte out of string literals,
+// quoted identifiers and comments, which are recognized by syn. If f returns
+// n > 0, n bytes are replaced by repl, or the byte is kept as is.
+func rewriteSQL(syn driver.Syntax, qstr string, f func(rest string) (repl string, n int)) string {
+	var buf strings.Builder
+	last := 0
+	syn.Scan(qstr, func(x int) int {
+		repl, n := f(qstr[x:])
+		if n > 0 {
+			buf.WriteString(qstr[last:x])
+			buf.WriteString(repl)
+			last = x + n
+		}
+		return n
+	})
+	buf.WriteString(qstr[last:])
+
+	return buf.String()
+}
@@ -0,0 +1,234 @@
+package sdm
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+	_ "github.com/Ronmi/sdm/driver/postgres"
+)
+
+// binderDriver is sqlite driver using numbered placeholders like "?1"
+type binderDriver struct {
+	driver.Driver
+}
+
+func (d binderDriver) Placeholder(n int) string {
+	return "?" + strconv.Itoa(n)
+}
+
+func (d binderDriver) Syntax() driver.Syntax {
+	return driver.SyntaxOf(d.Driver)
+}
+
+func initbinderdb(t *testing.T) *Manager {
+	driver.RegisterDriver("sqlite3numbered", func(p map[string]string) driver.Driver {
+		return binderDriver{driver.GetDriver("sqlite3")}
+	})
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3numbered")
+	m.Reg(testver{}, testsoftdel{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	return m
+}
+
+func TestPlaceholders(t *testing.T) {
+	m := initbinderdb(t)
+	p := m.Placeholders()
+	if !p.Numbered() {
+		t.Fatal("expected placeholders are numbered")
+	}
+	actual := p.Skip(1).Next(reflect.TypeOf("")) + " " + p.In([]int{1, 2})
+	if expect := "?2 IN (?3,?4)"; actual != expect {
+		t.Errorf("expected %s, got %s", expect, actual)
+	}
+	if p.Len() != 4 {
+		t.Errorf("expected 4 placeholders are allocated, got %d", p.Len())
+	}
+
+	if actual := m.SQLIn([]int{1, 2}); actual != "IN (?1,?2)" {
+		t.Errorf("unexpected IN clause: %s", actual)
+	}
+	if actual := m.HolderIns(testver{}); !reflect.DeepEqual(actual, []string{"?1", "?2"}) {
+		t.Errorf("unexpected placeholders: %v", actual)
+	}
+
+	m = initpkdb(t)
+	if p := m.Placeholders(); p.Numbered() || p.Next(reflect.TypeOf(1)) != "?" {
+		t.Error("expected unnumbered placeholders")
+	}
+}
+
+func TestPlaceholdersUpdate(t *testing.T) {
+	m := initbinderdb(t)
+	data := &testver{Name: "a"}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	qstr, vals, err := m.makeUpdate(data, `id=?1`, []interface{}{data.ID})
+	if err != nil {
+		t.Fatalf("cannot generate sql: %s", err)
+	}
+	expect := `UPDATE "testver" SET "name"=?2,"version"="version"+1 WHERE (id=?1) AND "testver"."version"=?3`
+	if qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+	if expect := []interface{}{data.ID, "a", 0}; !reflect.DeepEqual(vals, expect) {
+		t.Errorf("expected values %v, got %v", expect, vals)
+	}
+
+	data.Name = "b"
+	if _, err := m.Update(data, `id=?1`, data.ID); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	data.Name = "c"
+	if _, err := m.UpdateByPK(data); err != nil {
+		t.Fatalf("cannot update by pk: %s", err)
+	}
+	if data.Ver != 2 {
+		t.Errorf("expected version is increased to 2, got %d", data.Ver)
+	}
+
+	var actual testver
+	if err := m.LoadSimple(&actual, data.ID); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual != *data {
+		t.Errorf("expected %+v, got %+v", *data, actual)
+	}
+}
+
+func TestPlaceholdersBulk(t *testing.T) {
+	m := initbinderdb(t)
+	b := m.BulkInsert(testsoftdel{})
+	for _, n := range []string{"a", "b", "c"} {
+		b.Add(testsoftdel{Name: n})
+	}
+	qstr, _ := b.Make()
+	expect := `INSERT INTO "testsoftdel" ("name","deleted_at") VALUES (?1,?2),(?3,?4),(?5,?6)`
+	if qstr[0] != expect {
+		t.Errorf("expected %s, got %s", expect, qstr[0])
+	}
+	if _, err := m.RunBulk(b); err != nil {
+		t.Fatalf("cannot bulk insert: %s", err)
+	}
+
+	d := m.BulkDelete(testsoftdel{})
+	d.Add(testsoftdel{ID: 1, Name: "a"}, testsoftdel{ID: 2, Name: "b"})
+	qstr, _ = d.Make()
+	expect = `UPDATE "testsoftdel" SET "deleted_at"=?1 WHERE ` +
+		`("testsoftdel"."id"=?2 AND "testsoftdel"."name"=?3 AND "testsoftdel"."deleted_at" IS NULL) OR ` +
+		`("testsoftdel"."id"=?4 AND "testsoftdel"."name"=?5 AND "testsoftdel"."deleted_at" IS NULL)`
+	if qstr[0] != expect {
+		t.Errorf("expected %s, got %s", expect, qstr[0])
+	}
+	if _, err := m.RunBulk(d); err != nil {
+		t.Fatalf("cannot bulk delete: %s", err)
+	}
+	if _, err := m.DeleteByPK(&testsoftdel{ID: 3}); err != nil {
+		t.Fatalf("cannot delete by pk: %s", err)
+	}
+
+	if names := findNames(t, m.FindWithDeleted(testsoftdel{}, `deleted_at IS NULL OR name=?1`, "a")); len(names) != 1 || names[0] != "a" {
+		t.Errorf("expected only a is found, got %v", names)
+	}
+	if _, err := LoadByPK[testsoftdel](m, 1); err == nil {
+		t.Error("expected deleted row is not loaded")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	m := initbinderdb(t)
+	cases := []struct {
+		qstr   string
+		expect string
+		msg    string
+	}{
+		{`a=? AND b IN (?,?)`, `a=?1 AND b IN (?2,?3)`, "simple"},
+		{`a='?' AND b=?`, `a='?' AND b=?1`, "string literal"},
+		{`a='it''s?' AND b=?`, `a='it''s?' AND b=?1`, "escaped quote"},
+		{`p='C:\' AND id=?`, `p='C:\' AND id=?1`, "backslash"},
+		{`a ?| b AND c ?& d AND e ?? f AND g=?`, `a ?| b AND c ?& d AND e ? f AND g=?1`, "jsonb operators"},
+		{`"a?"=? AND ` + "`b?`=?", `"a?"=?1 AND ` + "`b?`=?2", "quoted identifier"},
+		{"a=? -- b=?\nAND c=?", "a=?1 -- b=?\nAND c=?2", "line comment"},
+		{`a=? /* b=? */ AND c=?`, `a=?1 /* b=? */ AND c=?2`, "block comment"},
+		{`a=?1 AND b=?`, `a=?1 AND b=?1`, "numbered already"},
+		{`a=1`, `a=1`, "no placeholder"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			if actual := m.Placeholders().Rebind(c.qstr); actual != c.expect {
+				t.Errorf("expected %s, got %s", c.expect, actual)
+			}
+		})
+	}
+
+	if actual := m.Placeholders().Skip(2).Rebind(`a=?`); actual != `a=?3` {
+		t.Errorf("expected numbering starts after skipped ones, got %s", actual)
+	}
+	if actual := initpkdb(t).Placeholders().Rebind(`a=?`); actual != `a=?` {
+		t.Errorf("expected unnumbered placeholders untouched, got %s", actual)
+	}
+
+	// literal rules are driver-specific
+	mysql := binderDriver{driver.GetDriver("mysql")}
+	p := &Placeholders{drv: mysql, bind: mysql}
+	if actual := p.Rebind(`a='\'?' AND b=?`); actual != `a='\'?' AND b=?1` {
+		t.Errorf("expected backslash escaping in mysql, got %s", actual)
+	}
+	qstr := `SELECT $$?$$, $tag$'?$tag$, E'\'?', ? FROM t WHERE data ?| array['a']`
+	expect := `SELECT $$?$$, $tag$'?$tag$, E'\'?', $1 FROM t WHERE data ?| array['a']`
+	if actual := New(nil, "postgres").Placeholders().Rebind(qstr); actual != expect {
+		t.Errorf("expected %s, got %s", expect, actual)
+	}
+}
+
+func TestManagerRebind(t *testing.T) {
+	m := initbinderdb(t)
+	m.Rebind = true
+
+	if _, err := m.Exec(`INSERT INTO testver (name, version) VALUES (?, ?)`, "a", 0); err != nil {
+		t.Fatalf("cannot exec: %s", err)
+	}
+	var data testver
+	if err := m.QueryRow(&data, `SELECT %cols% FROM %table% WHERE name=? AND version=?`, "a", 0); err != nil {
+		t.Fatalf("cannot query: %s", err)
+	}
+	if data.ID != 1 {
+		t.Fatalf("expected inserted row is loaded, got %+v", data)
+	}
+
+	data.Name = "b"
+	if _, err := m.Update(&data, `id=? AND name<>?`, data.ID, "b"); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if cnt, err := m.Select(testver{}).Where("name=? AND version=?", "b", 1).Count(); err != nil || cnt != 1 {
+		t.Errorf("expected 1 row is counted, got %d (%v)", cnt, err)
+	}
+	var list []testver
+	if err := m.Select(testver{}).Where("name=?", "b").All(&list); err != nil || len(list) != 1 {
+		t.Errorf("expected 1 row is selected, got %+v (%v)", list, err)
+	}
+
+	tx, err := m.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE testver SET name=? WHERE id=?`, "c", data.ID); err != nil {
+		t.Fatalf("cannot exec in tx: %s", err)
+	}
+	var actual testver
+	if err := tx.QueryRow(&actual, `SELECT %cols% FROM %table% WHERE id=? AND version=?`, data.ID, 1); err != nil {
+		t.Fatalf("cannot query in tx: %s", err)
+	}
+	if actual.Name != "c" {
+		t.Errorf("expected name to be c, got %+v", actual)
+	}
+}
@@ -0,0 +1,542 @@
+package sdm
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// return -1 if not found
+func findIndexByName(i *[]driver.Index, name, typ string) int {
+	arr := *i
+	for idx, _ := range arr {
+		if arr[idx].Name == name {
+			return idx
+		}
+	}
+
+	idx := len(arr)
+	*i = append(arr, driver.Index{
+		Type: typ,
+		Name: name,
+	})
+	return idx
+}
+
+func (m *Manager) has(t reflect.Type) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if _, ok := m.info[t]; ok {
+		return true
+	}
+	return false
+}
+
+// Reg calls Register for you, just for short. It panics at first error
+//
+// It will use struct name (convert to lower case) as table name.
+func (m *Manager) Reg(data ...interface{}) {
+	if err := m.RegE(data...); err != nil {
+		panic(err)
+	}
+}
+
+// RegE is like Reg, but returns *ErrRegister instead of panicking.
+//
+// Types before the failed one are registered.
+func (m *Manager) RegE(data ...interface{}) error {
+	for _, i := range data {
+		t := reflect.Indirect(reflect.ValueOf(i)).Type()
+		if err := m.registerE(t, strings.ToLower(t.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Register parses and caches a type into SDM. It panics at first error
+func (m *Manager) Register(i interface{}, tableName string) {
+	if err := m.RegisterE(i, tableName); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterE is like Register, but returns *ErrRegister instead of panicking.
+func (m *Manager) RegisterE(i interface{}, tableName string) error {
+	t := reflect.Indirect(reflect.ValueOf(i)).Type()
+	return m.registerE(t, tableName)
+}
+
+// RegisterWas is like Register, with previous names of the table. They are
+// used by PlanMigration and CreateTablesNotExist to rename existing table.
+func (m *Manager) RegisterWas(i interface{}, tableName string, was ...string) {
+	if err := m.RegisterWasE(i, tableName, was...); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterWasE is like RegisterWas, but returns *ErrRegister instead of
+// panicking.
+func (m *Manager) RegisterWasE(i interface{}, tableName string, was ...string) error {
+	t := reflect.Indirect(reflect.ValueOf(i)).Type()
+	return m.registerE(t, tableName, was...)
+}
+
+func (m *Manager) register(t reflect.Type, tableName string) {
+	if err := m.registerE(t, tableName); err != nil {
+		panic(err)
+	}
+}
+
+func (m *Manager) registerE(t reflect.Type, tableName string, was ...string) error {
+	if m.has(t) {
+		return nil
+	}
+
+	info, err := parseInfo(t, tableName)
+	if err != nil {
+		return err
+	}
+	info.Was = was
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.info[t]; !ok {
+		m.info[t] = info
+	}
+
+	return nil
+}
+
+// isEmbeddable determins if a field is an anonymous struct (or pointer to
+// struct) which should be flattened
+func isEmbeddable(f reflect.StructField) bool {
+	if !f.Anonymous || f.Tag.Get("sdm") != "" {
+		return false
+	}
+
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		if f.PkgPath != "" {
+			// cannot allocate unexported pointer
+			return false
+		}
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// isInteger determins if t can be used as version column
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// splitTag splits sdm tag by comma, ignoring commas in parentheses or single
+// quotes, so "type=DECIMAL(10,2)" and "comment='a, b'" are kept.
+func splitTag(tag string) (ret []string) {
+	depth := 0
+	quoted := false
+	start := 0
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			ret = append(ret, tag[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(ret, tag[start:])
+}
+
+// parseOption parses "key=value" style column options into fdef
+func parseOption(fdef *driver.Column, tag string) (ok bool, err error) {
+	switch tag {
+	case "null", "notnull":
+		v := tag == "null"
+		if fdef.Nullable != nil && *fdef.Nullable != v {
+			return true, ErrInvalidOption
+		}
+		fdef.Nullable = &v
+		return true, nil
+	}
+
+	idx := strings.Index(tag, "=")
+	if idx < 0 {
+		return false, nil
+	}
+	val := tag[idx+1:]
+
+	switch tag[:idx] {
+	case "type":
+		fdef.Type = val
+	case "size":
+		n, e := strconv.Atoi(val)
+		if e != nil || n <= 0 {
+			return true, ErrInvalidOption
+		}
+		fdef.Size = n
+	case "default":
+		fdef.Default = val
+	case "comment":
+		if l := len(val); l >= 2 && val[0] == '\'' && val[l-1] == '\'' {
+			val = val[1 : l-1]
+		}
+		fdef.Comment = val
+	case "was":
+		fdef.Was = val
+	default:
+		return false, nil
+	}
+
+	if val == "" {
+		return true, ErrInvalidOption
+	}
+	return true, nil
+}
+
+// parseForeign parses "fk_name=table.col" tag
+func parseForeign(tag string) (name, table, col string, ok bool) {
+	eq := strings.Index(tag, "=")
+	if eq < 0 {
+		return
+	}
+	name = tag[len(driver.IndexTypeForeign)+1 : eq]
+	ref := tag[eq+1:]
+	dot := strings.LastIndex(ref, ".")
+	if name == "" || dot <= 0 || dot == len(ref)-1 {
+		return
+	}
+
+	return name, ref[:dot], ref[dot+1:], true
+}
+
+// parseAction converts referential action like "set_null" into SQL syntax
+func parseAction(act string) (ret string, ok bool) {
+	ret = strings.ToUpper(strings.Replace(act, "_", " ", -1))
+	switch ret {
+	case "CASCADE", "RESTRICT", "SET NULL", "SET DEFAULT", "NO ACTION":
+		return ret, true
+	}
+	return "", false
+}
+
+// parseInline returns column prefix if the tag has "inline" property
+func parseInline(tag string) (prefix string, ok bool) {
+	tags := splitTag(tag)
+	for _, t := range tags[1:] {
+		if t == "inline" {
+			return tags[0], true
+		}
+	}
+
+	return
+}
+
+func parseInfo(t reflect.Type, tableName string) (*tableInfo, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, &ErrRegister{Type: t, Reason: ErrNotStruct}
+	}
+
+	mps := make([]driver.Column, 0, t.NumField())
+	idx := make(map[string]driver.Column)
+	indexes := []driver.Index{}
+
+	// some flags
+	havePK := false
+	var lastAIField *driver.Column
+	aiFieldCnt := 0
+	ver := ""
+	softDel := ""
+	created := ""
+	updated := ""
+
+	// parseField parses a tagged field, index is the index path of the field
+	parseField := func(f reflect.StructField, index []int, prefix string) error {
+		tags := splitTag(f.Tag.Get("sdm"))
+		col := tags[0]
+		tags = tags[1:]
+
+		if col == "" {
+			return &ErrRegister{Type: t, Field: f.Name, Reason: ErrEmptyColumn}
+		}
+		col = prefix + col
+		if _, ok := idx[col]; ok {
+			return &ErrRegister{Type: t, Field: f.Name, Reason: ErrDuplicateColumn}
+		}
+
+		fdef := driver.Column{Index: index, Name: col}
+		fks := []int{} // foreign keys of this field
+		var onDelete, onUpdate string
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, "ondelete=") || strings.HasPrefix(tag, "onupdate=") {
+				act, ok := parseAction(tag[9:])
+				if !ok {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrInvalidOption}
+				}
+				if strings.HasPrefix(tag, "ondelete=") {
+					onDelete = act
+				} else {
+					onUpdate = act
+				}
+				continue
+			}
+
+			if strings.HasPrefix(tag, driver.IndexTypeForeign+"_") {
+				name, table, refCol, ok := parseForeign(tag)
+				if !ok {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrInvalidOption}
+				}
+				pos := findIndexByName(&indexes, prefix+name, driver.IndexTypeForeign)
+				i := &indexes[pos]
+				if i.Type != driver.IndexTypeForeign {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrIndexConflict}
+				}
+				if i.Ref == nil {
+					i.Ref = &driver.ForeignKey{Table: table}
+				}
+				if i.Ref.Table != table {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrIndexConflict}
+				}
+				i.Cols = append(i.Cols, col)
+				i.Ref.Cols = append(i.Ref.Cols, refCol)
+				fks = append(fks, pos)
+				continue
+			}
+
+			if ok, err := parseOption(&fdef, tag); ok {
+				if err != nil {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: err}
+				}
+				continue
+			}
+
+			if tag == "ai" {
+				if aiFieldCnt > 0 {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrMultipleAI}
+				}
+				fdef.AI = true
+				aiFieldCnt++
+				lastAIField = &fdef
+				continue
+			}
+
+			if tag == "ver" {
+				if ver != "" {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrMultipleVer}
+				}
+				if !isInteger(f.Type) {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrVerType}
+				}
+				ver = col
+				continue
+			}
+
+			if tag == "softdel" {
+				if softDel != "" {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrMultipleSoftDel}
+				}
+				if f.Type != nullTimeType {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrSoftDelType}
+				}
+				softDel = col
+				continue
+			}
+
+			if tag == "created" || tag == "updated" {
+				target := &created
+				if tag == "updated" {
+					target = &updated
+				}
+				if *target != "" {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrMultipleTimestamp}
+				}
+				if !isTimestampType(f.Type) {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrTimestampType}
+				}
+				*target = col
+				continue
+			}
+
+			for _, typ := range []string{driver.IndexTypeIndex, driver.IndexTypePrimary, driver.IndexTypeUnique} {
+				l := len(typ) + 1
+				if len(tag) < l {
+					continue
+				}
+
+				if !strings.HasPrefix(tag, typ+"_") {
+					continue
+				}
+
+				name := prefix + tag[l:]
+				pos := findIndexByName(&indexes, name, typ)
+				if indexes[pos].Type != typ {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrIndexConflict}
+				}
+				indexes[pos].Cols = append(indexes[pos].Cols, col)
+				if typ == driver.IndexTypePrimary {
+					havePK = true
+				}
+				break
+			}
+		}
+
+		if (onDelete != "" || onUpdate != "") && len(fks) == 0 {
+			return &ErrRegister{Type: t, Field: f.Name, Reason: ErrInvalidOption}
+		}
+		for _, pos := range fks {
+			if onDelete != "" {
+				indexes[pos].Ref.OnDelete = onDelete
+			}
+			if onUpdate != "" {
+				indexes[pos].Ref.OnUpdate = onUpdate
+			}
+		}
+
+		if fdef.Was != "" {
+			fdef.Was = prefix + fdef.Was
+		}
+
+		mps = append(mps, fdef)
+		idx[col] = fdef
+		return nil
+	}
+
+	// walk visits fields depth-first, flattening embedded and inline structs
+	var walk func(st reflect.Type, base []int, prefix string, visiting map[reflect.Type]bool) error
+	walk = func(st reflect.Type, base []int, prefix string, visiting map[reflect.Type]bool) error {
+		visiting[st] = true
+		defer delete(visiting, st)
+
+		for i := 0; i < st.NumField(); i++ {
+			f := st.Field(i)
+			index := make([]int, len(base)+1)
+			copy(index, base)
+			index[len(base)] = i
+
+			if isEmbeddable(f) {
+				et := f.Type
+				if et.Kind() == reflect.Ptr {
+					et = et.Elem()
+				}
+				if visiting[et] {
+					// recursive embedding, skip
+					continue
+				}
+				if err := walk(et, index, prefix, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+
+			tag := f.Tag.Get("sdm")
+			if tag == "" {
+				// not decorated, skip
+				continue
+			}
+
+			if f.PkgPath != "" {
+				// not exported, skip
+				continue
+			}
+
+			if p, ok := parseInline(tag); ok {
+				et := f.Type
+				if et.Kind() == reflect.Ptr {
+					et = et.Elem()
+				}
+				if et.Kind() != reflect.Struct {
+					return &ErrRegister{Type: t, Field: f.Name, Reason: ErrNotStruct}
+				}
+				if visiting[et] {
+					// recursive inlining, skip
+					continue
+				}
+				if err := walk(et, index, prefix+p, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := parseField(f, index, prefix); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(t, nil, "", map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+
+	if !havePK && aiFieldCnt == 1 {
+		// Use AI field as primary key
+		indexes = append(indexes, driver.Index{
+			Type: driver.IndexTypePrimary,
+			Name: tableName + "_" + "pk",
+			Cols: []string{lastAIField.Name},
+		})
+		havePK = true
+	}
+
+	pk := -1
+	if havePK {
+		for x, i := range indexes {
+			if i.Type == driver.IndexTypePrimary {
+				pk = x
+				break
+			}
+		}
+	}
+
+	return &tableInfo{
+		Type:    t,
+		Table:   tableName,
+		Indexes: indexes,
+		Defs:    idx,
+		Fields:  mps,
+		PKIndex: pk,
+		Ver:     ver,
+		SoftDel: softDel,
+		Created: created,
+		Updated: updated,
+	}, nil
+}
+
+// fieldByIndex returns nested field of v by index path. Nil embedded pointers
+// are allocated if alloc is true, otherwise ok is false.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (ret reflect.Value, ok bool) {
+	for x, i := range index {
+		if x > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	return v, true
+}
@@ -0,0 +1,322 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testRegEmptyCol struct {
+	A int `sdm:",ai"`
+}
+
+type testRegDupCol struct {
+	A int `sdm:"a"`
+	B int `sdm:"a"`
+}
+
+type testRegMultiAI struct {
+	A int `sdm:"a,ai"`
+	B int `sdm:"b,ai"`
+}
+
+type testRegIdxConflict struct {
+	A int `sdm:"a,idx_x"`
+	B int `sdm:"b,uniq_x"`
+}
+
+func TestRegisterE(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   interface{}
+		field  string
+		reason error
+	}{
+		{"NotStruct", 1, "", ErrNotStruct},
+		{"EmptyColumn", testRegEmptyCol{}, "A", ErrEmptyColumn},
+		{"DuplicateColumn", testRegDupCol{}, "B", ErrDuplicateColumn},
+		{"MultipleAI", testRegMultiAI{}, "B", ErrMultipleAI},
+		{"IndexConflict", testRegIdxConflict{}, "B", ErrIndexConflict},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := New(nil, "sqlite3")
+			err := m.RegisterE(c.data, "test")
+			if !errors.Is(err, c.reason) {
+				t.Fatalf("expected %v, got %v", c.reason, err)
+			}
+
+			var e *ErrRegister
+			if !errors.As(err, &e) {
+				t.Fatalf("expected *ErrRegister, got %T", err)
+			}
+			if e.Field != c.field {
+				t.Errorf("expected field %s, got %s", c.field, e.Field)
+			}
+			if m.has(e.Type) {
+				t.Error("failed type should not be registered")
+			}
+		})
+	}
+
+	t.Run("Panic", func(t *testing.T) {
+		m := New(nil, "sqlite3")
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected Reg to panic")
+			}
+		}()
+		m.Reg(testRegDupCol{})
+	})
+}
+
+func TestNotRegistered(t *testing.T) {
+	m := New(nil, "sqlite3")
+	var e *ErrNotRegistered
+
+	if _, err := m.ColE(testok{}, 0); !errors.As(err, &e) {
+		t.Errorf("ColE: expected *ErrNotRegistered, got %v", err)
+	}
+	if _, err := m.ValE(testok{}); !errors.As(err, &e) {
+		t.Errorf("ValE: expected *ErrNotRegistered, got %v", err)
+	}
+	if _, err := m.HolderInsE(testok{}); !errors.As(err, &e) {
+		t.Errorf("HolderInsE: expected *ErrNotRegistered, got %v", err)
+	}
+	if _, err := m.QueryE(testok{}, `SELECT 1`); !errors.As(err, &e) {
+		t.Errorf("QueryE: expected *ErrNotRegistered, got %v", err)
+	}
+
+	m.AutoReg = true
+	cols, err := m.ColInsE(testok{})
+	if err != nil {
+		t.Fatalf("unexpected error with AutoReg: %s", err)
+	}
+	if l := len(cols); l != 3 {
+		t.Errorf("expected 3 columns, got %d", l)
+	}
+}
+
+type testBaseModel struct {
+	ID int `sdm:"id,ai"`
+}
+
+type testTimestamps struct {
+	Created int64 `sdm:"created"`
+}
+
+type testEmbedded struct {
+	testBaseModel
+	*testTimestamps
+	Name string `sdm:"name"`
+}
+
+type testEmbeddedPtr struct {
+	*TestEmbeddedBase
+	Name string `sdm:"name"`
+}
+
+type TestEmbeddedBase struct {
+	ID      int   `sdm:"id,ai"`
+	Created int64 `sdm:"created"`
+}
+
+func TestEmbedded(t *testing.T) {
+	db := newdb(t)
+	m := New(db, "sqlite3")
+	if err := m.RegE(testEmbedded{}, testEmbeddedPtr{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	info := m.getInfo(reflect.TypeOf(testEmbedded{}))
+	if l := len(info.Fields); l != 2 {
+		// unexported embedded pointer is skipped
+		t.Fatalf("expected 2 columns, got %d: %+v", l, info.Fields)
+	}
+	if idx := info.Defs["id"].Index; !reflect.DeepEqual(idx, []int{0, 0}) {
+		t.Errorf("expected index path of id to be [0 0], got %v", idx)
+	}
+	if info.PKIndex < 0 {
+		t.Error("expected ai field of embedded struct to be primary key")
+	}
+
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	t.Run("Value", func(t *testing.T) {
+		data := testEmbedded{Name: "value"}
+		if _, err := m.Insert(&data); err != nil {
+			t.Fatalf("cannot insert: %s", err)
+		}
+		if data.ID != 1 {
+			t.Errorf("expected id to be filled back, got %d", data.ID)
+		}
+
+		var actual testEmbedded
+		if err := m.LoadSimple(&actual, 1); err != nil {
+			t.Fatalf("cannot load: %s", err)
+		}
+		if actual.ID != 1 || actual.Name != "value" {
+			t.Errorf("unexpected result: %+v", actual)
+		}
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		// nil embedded pointer is treated as NULL
+		if _, err := m.Insert(testEmbeddedPtr{Name: "nil"}); err == nil {
+			t.Error("expected NOT NULL constraint to fail with nil embedded pointer")
+		}
+
+		data := testEmbeddedPtr{
+			TestEmbeddedBase: &TestEmbeddedBase{Created: 100},
+			Name:             "pointer",
+		}
+		if _, err := m.Insert(&data); err != nil {
+			t.Fatalf("cannot insert: %s", err)
+		}
+		if data.ID != 1 {
+			t.Errorf("expected id to be filled back, got %d", data.ID)
+		}
+
+		var actual testEmbeddedPtr
+		if err := m.QueryRow(&actual, `SELECT %cols% FROM %table%`); err != nil {
+			t.Fatalf("cannot load: %s", err)
+		}
+		if actual.TestEmbeddedBase == nil {
+			t.Fatal("expected embedded pointer to be allocated")
+		}
+		if actual.ID != 1 || actual.Created != 100 || actual.Name != "pointer" {
+			t.Errorf("unexpected result: %+v %+v", actual, *actual.TestEmbeddedBase)
+		}
+	})
+}
+
+type testAddress struct {
+	Street string `sdm:"street"`
+	City   string `sdm:"city,idx_city"`
+}
+
+type testOrder struct {
+	ID       int          `sdm:"id,ai"`
+	Shipping testAddress  `sdm:"ship_,inline"`
+	Billing  *testAddress `sdm:"bill_,inline"`
+}
+
+func TestInline(t *testing.T) {
+	db := newdb(t)
+	m := New(db, "sqlite3")
+	if err := m.RegE(testOrder{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	info := m.getInfo(reflect.TypeOf(testOrder{}))
+	expect := map[string][]int{
+		"id":          {0},
+		"ship_street": {1, 0},
+		"ship_city":   {1, 1},
+		"bill_street": {2, 0},
+		"bill_city":   {2, 1},
+	}
+	if l := len(info.Fields); l != len(expect) {
+		t.Fatalf("expected %d columns, got %d: %+v", len(expect), l, info.Fields)
+	}
+	for col, idx := range expect {
+		if actual := info.Defs[col].Index; !reflect.DeepEqual(actual, idx) {
+			t.Errorf("expected index path of %s to be %v, got %v", col, idx, actual)
+		}
+	}
+	names := map[string]bool{}
+	for _, i := range info.Indexes {
+		names[i.Name] = true
+	}
+	for _, name := range []string{"ship_city", "bill_city"} {
+		if !names[name] {
+			t.Errorf("expected index %s to be prefixed", name)
+		}
+	}
+
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	data := testOrder{
+		Shipping: testAddress{Street: "s1", City: "c1"},
+		Billing:  &testAddress{Street: "s2", City: "c2"},
+	}
+	if _, err := m.Insert(&data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	var actual testOrder
+	if err := m.LoadSimple(&actual, data.ID); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual.Shipping != data.Shipping {
+		t.Errorf("expected shipping address %+v, got %+v", data.Shipping, actual.Shipping)
+	}
+	if actual.Billing == nil || *actual.Billing != *data.Billing {
+		t.Errorf("expected billing address %+v, got %+v", data.Billing, actual.Billing)
+	}
+
+	t.Run("NotStruct", func(t *testing.T) {
+		type wrong struct {
+			A int `sdm:"a_,inline"`
+		}
+		if err := m.RegE(wrong{}); !errors.Is(err, ErrNotStruct) {
+			t.Errorf("expected ErrNotStruct, got %v", err)
+		}
+	})
+}
+
+func TestSplitTag(t *testing.T) {
+	cases := map[string][]string{
+		"id,ai":                            {"id", "ai"},
+		"price,type=DECIMAL(10,2),notnull": {"price", "type=DECIMAL(10,2)", "notnull"},
+		"name,comment='a, (b'":             {"name", "comment='a, (b'"},
+		"name":                             {"name"},
+	}
+	for tag, expect := range cases {
+		if actual := splitTag(tag); !reflect.DeepEqual(actual, expect) {
+			t.Errorf("%s: expected %#v, got %#v", tag, expect, actual)
+		}
+	}
+}
+
+type testColumnOption struct {
+	ID    int     `sdm:"id,ai"`
+	Name  string  `sdm:"name,size=64,default='',comment='user name, in full'"`
+	Price float64 `sdm:"price,type=DECIMAL(10,2),null"`
+	Memo  *string `sdm:"memo,notnull,default='none'"`
+}
+
+func TestColumnOption(t *testing.T) {
+	m := New(newdb(t), "sqlite3")
+	m.Reg(testColumnOption{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create table: %s", err)
+	}
+	defs := m.getInfo(reflect.TypeOf(testColumnOption{})).Defs
+
+	if c := defs["name"]; c.Size != 64 || c.Default != "''" || c.Comment != "user name, in full" || c.Nullable != nil {
+		t.Errorf("unexpected options of name: %+v", c)
+	}
+	if c := defs["price"]; c.Type != "DECIMAL(10,2)" || c.Nullable == nil || !*c.Nullable {
+		t.Errorf("unexpected options of price: %+v", c)
+	}
+	if c := defs["memo"]; c.Default != "'none'" || c.Nullable == nil || *c.Nullable {
+		t.Errorf("unexpected options of memo: %+v", c)
+	}
+
+	for _, tag := range []string{"a,size=x", "a,size=0", "a,null,notnull", "a,type=", "a,was="} {
+		typ := reflect.StructOf([]reflect.StructField{{
+			Name: "A",
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(`sdm:"` + tag + `"`),
+		}})
+		if err := m.RegE(reflect.New(typ).Elem().Interface()); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s: expected ErrInvalidOption, got %v", tag, err)
+		}
+	}
+}
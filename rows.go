@@ -52,7 +52,7 @@ func (r *Rows) Scan(data interface{}) (err error) {
 
 	holders := make([]interface{}, len(r.columns))
 	for idx, col := range r.columns {
-		vf := vstruct.Field(r.def[col].ID)
+		vf, _ := fieldByIndex(vstruct, r.def[col].Index, true)
 
 		if val, ok := r.drv.GetScanner(vf); ok {
 			holders[idx] = val
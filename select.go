@@ -0,0 +1,360 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// SelectBuilder builds simple SELECT statements of a registered type, created
+// by Manager.Select or Tx.Select.
+//
+//     var list []Member
+//     err := m.Select(Member{}).
+//         Where("group_id = ?", 3).
+//         OrderBy("cd DESC").
+//         Limit(10).Offset(20).
+//         All(&list)
+//
+// Column names in OrderBy and the leading column of Where are validated and
+// quoted by driver, rest of Where conditions is raw SQL. Errors are kept and
+// returned when executing, and ErrUnknownColumn or ErrInvalidClause is
+// returned for bad column name or clause. Like Find, soft deleted rows are
+// excluded unless WithDeleted is called.
+//
+// Use Query for complex statements like JOIN or sub-query.
+type SelectBuilder struct {
+	m      *Manager
+	c      conn
+	typ    interface{}
+	info   *tableInfo
+	conds  []string
+	args   []interface{}
+	orders []string
+	limit  int
+	offset int
+	all    bool // include soft deleted rows
+	err    error
+}
+
+// Select creates a SelectBuilder for typ, which must be a registered type.
+func (m *Manager) Select(typ interface{}) *SelectBuilder {
+	return m.selectBuilder(m.db, typ)
+}
+
+func (m *Manager) selectBuilder(c conn, typ interface{}) *SelectBuilder {
+	info, err := m.lookupInfoOf(typ)
+	return &SelectBuilder{
+		m:     m,
+		c:     c,
+		typ:   reflect.Indirect(reflect.ValueOf(typ)).Interface(),
+		info:  info,
+		limit: -1,
+		err:   err,
+	}
+}
+
+func (b *SelectBuilder) fail(err error) *SelectBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// col validates and quotes column name
+func (b *SelectBuilder) col(name string) (string, bool) {
+	if b.info == nil {
+		return "", false
+	}
+	if _, ok := b.info.Defs[name]; !ok {
+		b.fail(fmt.Errorf("%w: %s", ErrUnknownColumn, name))
+		return "", false
+	}
+	return b.m.drv.Col(b.info.Table, name, driver.QWhere), true
+}
+
+// Where adds a condition like "col = ?", conditions are joined with AND.
+//
+// Condition must start with a column name, which is the only part validated and
+// quoted. Rest of it is raw SQL and used as is, so other column names in it are
+// not validated and never pass user input there, use "?" as placeholder
+// instead. Placeholders are converted to native ones of driver.
+func (b *SelectBuilder) Where(cond string, args ...interface{}) *SelectBuilder {
+	cond = strings.TrimSpace(cond)
+	n := 0
+	for n < len(cond) && isNameByte(cond[n], n > 0) {
+		n++
+	}
+	if n == 0 {
+		return b.fail(fmt.Errorf("%w: %s", ErrInvalidClause, cond))
+	}
+
+	col, ok := b.col(cond[:n])
+	if !ok {
+		return b
+	}
+	b.conds = append(b.conds, col+cond[n:])
+	b.args = append(b.args, args...)
+	return b
+}
+
+// OrderBy adds sorting columns like "col" or "col DESC"
+func (b *SelectBuilder) OrderBy(orders ...string) *SelectBuilder {
+	for _, o := range orders {
+		f := strings.Fields(o)
+		if len(f) < 1 || len(f) > 2 {
+			return b.fail(fmt.Errorf("%w: %s", ErrInvalidClause, o))
+		}
+		col, ok := b.col(f[0])
+		if !ok {
+			return b
+		}
+		if len(f) == 2 {
+			dir := strings.ToUpper(f[1])
+			if dir != "ASC" && dir != "DESC" {
+				return b.fail(fmt.Errorf("%w: %s", ErrInvalidClause, o))
+			}
+			col += " " + dir
+		}
+		b.orders = append(b.orders, col)
+	}
+	return b
+}
+
+// Limit sets max number of rows, negative n means no limit
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+// Offset skips first n rows, it must be used with Limit
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = n
+	return b
+}
+
+// WithDeleted includes soft deleted rows
+func (b *SelectBuilder) WithDeleted() *SelectBuilder {
+	b.all = true
+	return b
+}
+
+// where generates WHERE clause, or empty string if no condition
+func (b *SelectBuilder) where() string {
+	conds := b.conds
+	if !b.all {
+		if c := b.m.aliveCond(b.info); c != "" {
+			conds = append(conds[:len(conds):len(conds)], c)
+		}
+	}
+
+	switch len(conds) {
+	case 0:
+		return ""
+	case 1:
+		return ` WHERE ` + conds[0]
+	}
+	return ` WHERE (` + strings.Join(conds, ") AND (") + `)`
+}
+
+// SQL returns generated statement and arguments
+func (b *SelectBuilder) SQL() (string, []interface{}, error) {
+	return b.build(b.limit)
+}
+
+func (b *SelectBuilder) build(limit int) (qstr string, args []interface{}, err error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.offset > 0 && limit < 0 {
+		return "", nil, fmt.Errorf("%w: offset without limit", ErrInvalidClause)
+	}
+
+	qstr = `SELECT %cols% FROM %table%` + b.where()
+	if len(b.orders) > 0 {
+		qstr += ` ORDER BY ` + strings.Join(b.orders, ",")
+	}
+	if limit >= 0 {
+		qstr += ` LIMIT ` + strconv.Itoa(limit)
+	}
+	if b.offset > 0 {
+		qstr += ` OFFSET ` + strconv.Itoa(b.offset)
+	}
+
+	return b.m.Placeholders().Rebind(qstr), b.args, nil
+}
+
+func (b *SelectBuilder) rows(ctx context.Context, limit int) *Rows {
+	qstr, args, err := b.build(limit)
+	if err != nil {
+		if b.info == nil {
+			// not registered
+			return &Rows{e: err}
+		}
+		return b.m.createErrorRow(b.info.Type, err)
+	}
+	return b.m.queryNative(ctx, b.c, b.typ, qstr, args)
+}
+
+// Rows executes the query and returns the result
+func (b *SelectBuilder) Rows() *Rows {
+	return b.RowsContext(context.Background())
+}
+
+// RowsContext is context-aware version of Rows
+func (b *SelectBuilder) RowsContext(ctx context.Context) *Rows {
+	return b.rows(ctx, b.limit)
+}
+
+// All reads all matched rows into dst, which must be a pointer to slice of the
+// type or of pointer to the type.
+func (b *SelectBuilder) All(dst interface{}) error {
+	return b.AllContext(context.Background(), dst)
+}
+
+// AllContext is context-aware version of All
+func (b *SelectBuilder) AllContext(ctx context.Context, dst interface{}) error {
+	if b.err != nil {
+		return b.err
+	}
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: need pointer to slice, got %T", ErrInvalidDest, dst)
+	}
+	et := v.Elem().Type().Elem()
+	if et != b.info.Type && (et.Kind() != reflect.Ptr || et.Elem() != b.info.Type) {
+		return fmt.Errorf("%w: need slice of %s, got %T", ErrInvalidDest, b.info.Type, dst)
+	}
+
+	rows := b.rows(ctx, b.limit)
+	defer rows.Close()
+	v.Elem().Set(reflect.MakeSlice(v.Elem().Type(), 0, 0))
+	if err := rows.AppendTo(dst); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// One reads first matched row into data, sql.ErrNoRows is returned if nothing
+// matched.
+func (b *SelectBuilder) One(data interface{}) error {
+	return b.OneContext(context.Background(), data)
+}
+
+// OneContext is context-aware version of One
+func (b *SelectBuilder) OneContext(ctx context.Context, data interface{}) error {
+	rows := b.rows(ctx, 1)
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	return rows.Scan(data)
+}
+
+// Count returns number of matched rows, ordering, limit and offset are ignored
+func (b *SelectBuilder) Count() (int64, error) {
+	return b.CountContext(context.Background())
+}
+
+// CountContext is context-aware version of Count
+func (b *SelectBuilder) CountContext(ctx context.Context) (cnt int64, err error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	qstr := b.m.Placeholders().Rebind(`SELECT COUNT(*) FROM %table%` + b.where())
+	rows, err := b.c.QueryContext(ctx, b.m.expand(b.typ, qstr), b.args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&cnt)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	return
+}
+
+// selectByExample creates a SelectBuilder matching columns of example. Non-zero
+// fields are used if cols is empty.
+func (m *Manager) selectByExample(c conn, example interface{}, cols []string) *SelectBuilder {
+	b := m.selectBuilder(c, example)
+	if b.err != nil {
+		return b
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(example))
+	if len(cols) == 0 {
+		for _, f := range b.info.Fields {
+			if fv, ok := fieldByIndex(v, f.Index, false); ok && !fv.IsZero() {
+				cols = append(cols, f.Name)
+			}
+		}
+	}
+
+	for _, col := range cols {
+		f, ok := b.info.Defs[col]
+		if !ok {
+			return b.fail(fmt.Errorf("%w: %s", ErrUnknownColumn, col))
+		}
+		if val := m.fieldVal(v, f); isNull(val) {
+			b.Where(col + " IS NULL")
+		} else {
+			b.Where(col+" = ?", val)
+		}
+	}
+	return b
+}
+
+// FindByExample reads rows matching example into dst, which must be a pointer
+// to slice of the type or of pointer to the type.
+//
+// Non-zero fields of example are matched with "=", or columns in cols if
+// specified, which matches nil pointers with "IS NULL". Every row is matched if
+// no column is used. Like Find, soft deleted rows are excluded.
+//
+//     var list []Member
+//     err := m.FindByExample(&list, Member{GroupID: 3})
+func (m *Manager) FindByExample(dst, example interface{}, cols ...string) error {
+	return m.FindByExampleContext(context.Background(), dst, example, cols...)
+}
+
+// FindByExampleContext is context-aware version of FindByExample
+func (m *Manager) FindByExampleContext(ctx context.Context, dst, example interface{}, cols ...string) error {
+	return m.selectByExample(m.db, example, cols).AllContext(ctx, dst)
+}
+
+// CountByExample counts rows matching example, see FindByExample for detail.
+func (m *Manager) CountByExample(example interface{}, cols ...string) (int64, error) {
+	return m.CountByExampleContext(context.Background(), example, cols...)
+}
+
+// CountByExampleContext is context-aware version of CountByExample
+func (m *Manager) CountByExampleContext(ctx context.Context, example interface{}, cols ...string) (int64, error) {
+	return m.selectByExample(m.db, example, cols).CountContext(ctx)
+}
+
+// LoadByExample reads first row matching data into data, see FindByExample for
+// detail. Unlike LoadSimple, sql.ErrNoRows is returned if nothing matched.
+//
+//     user := User{Email: "john@example.com"}
+//     err := m.LoadByExample(&user)
+func (m *Manager) LoadByExample(data interface{}, cols ...string) error {
+	return m.LoadByExampleContext(context.Background(), data, cols...)
+}
+
+// LoadByExampleContext is context-aware version of LoadByExample
+func (m *Manager) LoadByExampleContext(ctx context.Context, data interface{}, cols ...string) error {
+	return m.selectByExample(m.db, data, cols).OneContext(ctx, data)
+}
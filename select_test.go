@@ -0,0 +1,210 @@
+package sdm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSelectSQL(t *testing.T) {
+	m := initsoftdeldb(t)
+	qstr, args, err := m.Select(testsoftdel{}).
+		Where("name <> ?", "a").
+		Where("id>?", 0).
+		OrderBy("name DESC", "id").
+		Limit(10).Offset(20).
+		SQL()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expect := `SELECT %cols% FROM %table% WHERE ("testsoftdel"."name" <> ?) AND ("testsoftdel"."id">?) AND ("testsoftdel"."deleted_at" IS NULL) ORDER BY "testsoftdel"."name" DESC,"testsoftdel"."id" LIMIT 10 OFFSET 20`
+	if qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", 0}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	qstr, _, _ = m.Select(testsoftdel{}).WithDeleted().SQL()
+	if expect := `SELECT %cols% FROM %table%`; qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+
+	qstr, _, _ = initbinderdb(t).Select(testver{}).Where("name = ?", "a").Where("version > ?", 1).SQL()
+	if expect := `SELECT %cols% FROM %table% WHERE ("testver"."name" = ?1) AND ("testver"."version" > ?2)`; qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+}
+
+func TestSelectInvalid(t *testing.T) {
+	m := initsoftdeldb(t)
+	cases := []struct {
+		b      *SelectBuilder
+		expect error
+		msg    string
+	}{
+		{m.Select(testsoftdel{}).Where("nope = ?", 1), ErrUnknownColumn, "unknown column in where"},
+		{m.Select(testsoftdel{}).Where("(id = ?)", 1), ErrInvalidClause, "where not starting with column"},
+		{m.Select(testsoftdel{}).OrderBy("nope"), ErrUnknownColumn, "unknown column in order"},
+		{m.Select(testsoftdel{}).OrderBy("id DOWN"), ErrInvalidClause, "invalid direction"},
+		{m.Select(testsoftdel{}).Offset(1), ErrInvalidClause, "offset without limit"},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			var list []testsoftdel
+			if err := c.b.All(&list); !errors.Is(err, c.expect) {
+				t.Errorf("expected %v, got %v", c.expect, err)
+			}
+		})
+	}
+
+	var e *ErrNotRegistered
+	if _, err := m.Select(testver{}).Count(); !errors.As(err, &e) {
+		t.Errorf("expected ErrNotRegistered, got %v", err)
+	}
+	if err := m.Select(testver{}).Rows().Err(); !errors.As(err, &e) {
+		t.Errorf("expected ErrNotRegistered from rows, got %v", err)
+	}
+	var wrong []testver
+	if err := m.Select(testsoftdel{}).All(&wrong); !errors.Is(err, ErrInvalidDest) {
+		t.Errorf("expected ErrInvalidDest for mismatched slice, got %v", err)
+	}
+	if err := m.Select(testsoftdel{}).All(wrong); !errors.Is(err, ErrInvalidDest) {
+		t.Errorf("expected ErrInvalidDest for non-pointer, got %v", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	m := initsoftdeldb(t)
+	if _, err := m.Delete(&testsoftdel{ID: 1, Name: "a"}); err != nil {
+		t.Fatalf("cannot delete: %s", err)
+	}
+
+	var list []testsoftdel
+	if err := m.Select(testsoftdel{}).OrderBy("id DESC").All(&list); err != nil {
+		t.Fatalf("cannot select: %s", err)
+	}
+	if len(list) != 2 || list[0].Name != "c" || list[1].Name != "b" {
+		t.Errorf("unexpected result: %+v", list)
+	}
+
+	var ptrs []*testsoftdel
+	if err := m.Select(testsoftdel{}).WithDeleted().OrderBy("id").Limit(2).Offset(1).All(&ptrs); err != nil {
+		t.Fatalf("cannot select with deleted: %s", err)
+	}
+	if len(ptrs) != 2 || ptrs[0].Name != "b" || ptrs[1].Name != "c" {
+		t.Errorf("unexpected result: %+v", ptrs)
+	}
+
+	var one testsoftdel
+	if err := m.Select(testsoftdel{}).Where("name = ?", "b").One(&one); err != nil {
+		t.Fatalf("cannot select one: %s", err)
+	}
+	if one.ID != 2 {
+		t.Errorf("unexpected result: %+v", one)
+	}
+	if err := m.Select(testsoftdel{}).Where("name = ?", "a").One(&one); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	cnt, err := m.Select(testsoftdel{}).Where("id >= ?", 1).Limit(1).Count()
+	if err != nil {
+		t.Fatalf("cannot count: %s", err)
+	}
+	if cnt != 2 {
+		t.Errorf("expected 2 rows, got %d", cnt)
+	}
+
+	tx, err := m.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Delete(&testsoftdel{ID: 2, Name: "b"}); err != nil {
+		t.Fatalf("cannot delete in tx: %s", err)
+	}
+	if names := findNames(t, tx.Select(testsoftdel{}).Rows()); !reflect.DeepEqual(names, []string{"c"}) {
+		t.Errorf("expected only c is found in tx, got %v", names)
+	}
+}
+
+func TestByExample(t *testing.T) {
+	m := initpkdb(t)
+	note := "note"
+	for _, data := range []testCompositePK{
+		{A: 1, B: "a", Score: 0.1},
+		{A: 1, B: "b", Score: 0.2, Note: &note},
+		{A: 2, B: "a"},
+	} {
+		if _, err := m.Insert(data); err != nil {
+			t.Fatalf("cannot insert: %s", err)
+		}
+	}
+
+	var list []testCompositePK
+	if err := m.FindByExample(&list, testCompositePK{A: 1}); err != nil {
+		t.Fatalf("cannot find: %s", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("expected 2 rows with a=1, got %+v", list)
+	}
+	if err := m.FindByExample(&list, &testCompositePK{A: 1}, "a", "note"); err != nil {
+		t.Fatalf("cannot find with columns: %s", err)
+	}
+	if len(list) != 1 || list[0].B != "a" {
+		t.Errorf("expected only row with NULL note, got %+v", list)
+	}
+
+	cases := []struct {
+		example testCompositePK
+		cols    []string
+		expect  int64
+	}{
+		{testCompositePK{B: "a"}, nil, 2},
+		{testCompositePK{A: 1, Note: &note}, nil, 1},
+		{testCompositePK{}, nil, 3},
+		{testCompositePK{}, []string{"score"}, 1},
+	}
+	for _, c := range cases {
+		cnt, err := m.CountByExample(c.example, c.cols...)
+		if err != nil {
+			t.Fatalf("cannot count %+v: %s", c.example, err)
+		}
+		if cnt != c.expect {
+			t.Errorf("expected %d rows matching %+v %v, got %d", c.expect, c.example, c.cols, cnt)
+		}
+	}
+
+	data := testCompositePK{A: 1, Note: &note}
+	if err := m.LoadByExample(&data); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if data.B != "b" || data.Score != 0.2 {
+		t.Errorf("unexpected result: %+v", data)
+	}
+	if err := m.LoadByExample(&testCompositePK{A: 3}); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+	if _, err := m.CountByExample(testCompositePK{}, "nope"); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+
+	tx, err := m.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin: %s", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Insert(testCompositePK{A: 2, B: "b"}); err != nil {
+		t.Fatalf("cannot insert in tx: %s", err)
+	}
+	if cnt, err := tx.CountByExample(testCompositePK{A: 2}); err != nil || cnt != 2 {
+		t.Errorf("expected 2 rows in tx, got %d (%v)", cnt, err)
+	}
+	if err := tx.FindByExample(&list, testCompositePK{A: 2}); err != nil || len(list) != 2 {
+		t.Errorf("expected 2 rows in tx, got %+v (%v)", list, err)
+	}
+	if err := tx.LoadByExample(&testCompositePK{A: 2, B: "b"}); err != nil {
+		t.Errorf("cannot load in tx: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"time"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+var nullTimeType = reflect.TypeOf((*time.Time)(nil))
+
+// softDelSet generates SET clause marking rows as deleted at t
+func (m *Manager) softDelSet(info *tableInfo, t time.Time, p *Placeholders) (set string, val interface{}) {
+	set = m.drv.Col(info.Table, info.SoftDel, driver.QUpdate) + "=" +
+		p.Next(nullTimeType)
+	v := reflect.ValueOf(&t)
+	if vsql, ok := m.drv.GetValuer(v); ok {
+		return set, vsql
+	}
+	return set, v.Interface()
+}
+
+// aliveCond generates WHERE clause excluding soft deleted rows, or empty
+// string if type does not support soft delete
+func (m *Manager) aliveCond(info *tableInfo) string {
+	if info.SoftDel == "" {
+		return ""
+	}
+	return m.drv.Col(info.Table, info.SoftDel, driver.QWhere) + " IS NULL"
+}
+
+// markDeleted fills soft delete field of data if it is a pointer
+func (m *Manager) markDeleted(info *tableInfo, data interface{}, t time.Time) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr {
+		return
+	}
+	f, ok := fieldByIndex(v.Elem(), info.Defs[info.SoftDel].Index, false)
+	if !ok || !f.CanSet() {
+		return
+	}
+	f.Set(reflect.ValueOf(&t))
+}
+
+func (m *Manager) makeSoftDelete(data interface{}, t time.Time) (qstr string, vals []interface{}) {
+	info := m.infoOf(data)
+	p := m.Placeholders()
+	set, val := m.softDelSet(info, t, p)
+	cond, condVals := m.matchCond(data, p)
+
+	qstr = `UPDATE ` + m.drv.Quote(info.Table) +
+		` SET ` + set +
+		` WHERE ` + cond
+	vals = append([]interface{}{val}, condVals...)
+	return
+}
+
+func (m *Manager) makeSoftDeleteByPK(data interface{}, t time.Time) (qstr string, vals []interface{}, err error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	info, err := m.lookupInfo(v.Type())
+	if err != nil {
+		return
+	}
+
+	p := m.Placeholders()
+	set, val := m.softDelSet(info, t, p)
+	cond, condVals, err := m.pkCond(info, v, p)
+	if err != nil {
+		return
+	}
+
+	qstr = `UPDATE ` + m.drv.Quote(info.Table) +
+		` SET ` + set +
+		` WHERE ` + cond + ` AND ` + m.aliveCond(info)
+	vals = append([]interface{}{val}, condVals...)
+	return
+}
+
+// HardDelete deletes data in db like Delete, but ignores soft delete column.
+// It panics if type is not registered and auto register is not enabled.
+func (m *Manager) HardDelete(data interface{}) (sql.Result, error) {
+	return m.HardDeleteContext(context.Background(), data)
+}
+
+// HardDeleteContext is context-aware version of HardDelete
+func (m *Manager) HardDeleteContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.hardDelete(ctx, m.db, data)
+}
+
+func (m *Manager) hardDelete(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	qstr, vals := m.makeDelete(data)
+	return m.checkAffected(c.ExecContext(ctx, qstr, vals...))
+}
+
+func (m *Manager) makeFind(typ interface{}, where string, withDeleted bool) string {
+	qstr := `SELECT %cols% FROM %table%`
+	cond := ""
+	if !withDeleted {
+		cond = m.aliveCond(m.infoOf(typ))
+	}
+
+	switch {
+	case where != "" && cond != "":
+		qstr += ` WHERE (` + where + `) AND ` + cond
+	case where != "":
+		qstr += ` WHERE ` + where
+	case cond != "":
+		qstr += ` WHERE ` + cond
+	}
+	return qstr
+}
+
+// Find selects rows of typ matching where, excluding soft deleted rows.
+// It panics if type is not registered and auto register is not enabled.
+//
+// Pass empty where to select all rows. You can use "%table%" in where.
+//
+//     rows := m.Find(User{}, `name=?`, "John")
+func (m *Manager) Find(typ interface{}, where string, args ...interface{}) *Rows {
+	return m.FindContext(context.Background(), typ, where, args...)
+}
+
+// FindContext is context-aware version of Find
+func (m *Manager) FindContext(ctx context.Context, typ interface{}, where string, args ...interface{}) *Rows {
+	return m.query(ctx, m.db, typ, m.makeFind(typ, where, false), args)
+}
+
+// FindWithDeleted is like Find, but includes soft deleted rows
+func (m *Manager) FindWithDeleted(typ interface{}, where string, args ...interface{}) *Rows {
+	return m.FindWithDeletedContext(context.Background(), typ, where, args...)
+}
+
+// FindWithDeletedContext is context-aware version of FindWithDeleted
+func (m *Manager) FindWithDeletedContext(ctx context.Context, typ interface{}, where string, args ...interface{}) *Rows {
+	return m.query(ctx, m.db, typ, m.makeFind(typ, where, true), args)
+}
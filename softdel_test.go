@@ -0,0 +1,164 @@
+package sdm
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testsoftdel struct {
+	ID        int        `sdm:"id,ai"`
+	Name      string     `sdm:"name"`
+	DeletedAt *time.Time `sdm:"deleted_at,softdel"`
+}
+
+func initsoftdeldb(t *testing.T) *Manager {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3:time=int")
+	m.Reg(testsoftdel{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	for _, n := range []string{"a", "b", "c"} {
+		if _, err := m.Insert(testsoftdel{Name: n}); err != nil {
+			t.Fatalf("cannot insert %s: %s", n, err)
+		}
+	}
+	return m
+}
+
+func findNames(t *testing.T, rows *Rows) (ret []string) {
+	defer rows.Close()
+	for rows.Next() {
+		var x testsoftdel
+		if err := rows.Scan(&x); err != nil {
+			t.Fatalf("cannot scan: %s", err)
+		}
+		ret = append(ret, x.Name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("cannot read rows: %s", err)
+	}
+	return
+}
+
+func TestSoftDelRegister(t *testing.T) {
+	m := New(nil, "sqlite3")
+	err := m.RegE(struct {
+		D time.Time `sdm:"d,softdel"`
+	}{})
+	if !errors.Is(err, ErrSoftDelType) {
+		t.Errorf("expected ErrSoftDelType, got %v", err)
+	}
+
+	err = m.RegE(struct {
+		D1 *time.Time `sdm:"d1,softdel"`
+		D2 *time.Time `sdm:"d2,softdel"`
+	}{})
+	if !errors.Is(err, ErrMultipleSoftDel) {
+		t.Errorf("expected ErrMultipleSoftDel, got %v", err)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	m := initsoftdeldb(t)
+
+	data := &testsoftdel{ID: 1, Name: "a"}
+	qstr, vals := m.makeSoftDelete(data, time.Now())
+	expect := `UPDATE "testsoftdel" SET "deleted_at"=? WHERE "testsoftdel"."id"=? AND "testsoftdel"."name"=? AND "testsoftdel"."deleted_at" IS NULL`
+	if qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+	if l := len(vals); l != 3 {
+		t.Errorf("expected 3 values, got %d", l)
+	}
+
+	if _, err := m.Delete(data); err != nil {
+		t.Fatalf("cannot delete: %s", err)
+	}
+	if data.DeletedAt == nil {
+		t.Errorf("expected deleted_at is filled")
+	}
+	if _, err := m.DeleteByPK(&testsoftdel{ID: 2}); err != nil {
+		t.Fatalf("cannot delete by pk: %s", err)
+	}
+
+	if cnt := countRows(t, m, "testsoftdel"); cnt != 3 {
+		t.Errorf("expected rows are kept, got %d rows", cnt)
+	}
+
+	if names := findNames(t, m.Find(testsoftdel{}, "")); len(names) != 1 || names[0] != "c" {
+		t.Errorf("expected only c is found, got %v", names)
+	}
+	if names := findNames(t, m.Find(testsoftdel{}, `name<>?`, "c")); len(names) != 0 {
+		t.Errorf("expected nothing found, got %v", names)
+	}
+	if names := findNames(t, m.FindWithDeleted(testsoftdel{}, `name<>?`, "c")); len(names) != 2 {
+		t.Errorf("expected a and b are found, got %v", names)
+	}
+
+	var loaded testsoftdel
+	if err := m.LoadSimple(&loaded, 1); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if loaded.ID != 0 {
+		t.Errorf("expected deleted row is not loaded, got %+v", loaded)
+	}
+	if _, err := LoadByPK[testsoftdel](m, 2); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows for deleted row, got %v", err)
+	}
+
+	// deleting again matches nothing
+	m.StrictDelete = true
+	if _, err := m.DeleteByPK(&testsoftdel{ID: 2}); err != ErrNoRowsAffected {
+		t.Errorf("expected ErrNoRowsAffected, got %v", err)
+	}
+
+	if _, err := m.HardDelete(data); err != nil {
+		t.Fatalf("cannot hard delete: %s", err)
+	}
+	if cnt := countRows(t, m, "testsoftdel"); cnt != 2 {
+		t.Errorf("expected a row is removed, got %d rows", cnt)
+	}
+}
+
+func TestSoftBulkDelete(t *testing.T) {
+	m := initsoftdeldb(t)
+
+	b := m.BulkDelete(testsoftdel{})
+	b.Add(testsoftdel{ID: 1, Name: "a"}, testsoftdel{ID: 2, Name: "b"})
+	if _, err := m.RunBulk(b); err != nil {
+		t.Fatalf("cannot run bulk delete: %s", err)
+	}
+
+	if cnt := countRows(t, m, "testsoftdel"); cnt != 3 {
+		t.Errorf("expected rows are kept, got %d rows", cnt)
+	}
+	if names := findNames(t, m.Find(testsoftdel{}, "")); len(names) != 1 || names[0] != "c" {
+		t.Errorf("expected only c is found, got %v", names)
+	}
+}
+
+func TestSoftBulkHardDelete(t *testing.T) {
+	m := initsoftdeldb(t)
+
+	c := &testsoftdel{ID: 3, Name: "c"}
+	if _, err := m.Delete(c); err != nil {
+		t.Fatalf("cannot soft delete: %s", err)
+	}
+
+	b := m.BulkHardDelete(testsoftdel{})
+	b.Add(testsoftdel{ID: 1, Name: "a"}, c)
+	if _, err := m.RunBulk(b); err != nil {
+		t.Fatalf("cannot run bulk hard delete: %s", err)
+	}
+
+	if cnt := countRows(t, m, "testsoftdel"); cnt != 1 {
+		t.Errorf("expected rows are removed, got %d rows", cnt)
+	}
+	if names := findNames(t, m.FindWithDeleted(testsoftdel{}, "")); len(names) != 1 || names[0] != "b" {
+		t.Errorf("expected only b is kept, got %v", names)
+	}
+}
@@ -1,6 +1,7 @@
 package sdm
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 	"sync"
@@ -27,12 +28,22 @@ func (s *Stmt) Close() (err error) {
 
 // Exec is identical to sql.Stmt.Exec
 func (s *Stmt) Exec(args ...interface{}) (sql.Result, error) {
-	return s.stmt.Exec(args...)
+	return s.ExecContext(context.Background(), args...)
+}
+
+// ExecContext is identical to sql.Stmt.ExecContext
+func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return s.stmt.ExecContext(ctx, args...)
 }
 
 // Query is just sql.Stmt.Query, excepts it wrap the sql.Rows in sdm.Rows
 func (s *Stmt) Query(args ...interface{}) *Rows {
-	r, err := s.stmt.Query(args...)
+	return s.QueryContext(context.Background(), args...)
+}
+
+// QueryContext is context-aware version of Query
+func (s *Stmt) QueryContext(ctx context.Context, args ...interface{}) *Rows {
+	r, err := s.stmt.QueryContext(ctx, args...)
 
 	s.lock.Lock()
 	if err == nil && s.columns == nil {
@@ -56,7 +67,12 @@ func (s *Stmt) Query(args ...interface{}) *Rows {
 
 // QueryRow is like Manager.QueryRow, but executes on prepared statement
 func (s *Stmt) QueryRow(data interface{}, args ...interface{}) error {
-	rows := s.Query(args...)
+	return s.QueryRowContext(context.Background(), data, args...)
+}
+
+// QueryRowContext is context-aware version of QueryRow
+func (s *Stmt) QueryRowContext(ctx context.Context, data interface{}, args ...interface{}) error {
+	rows := s.QueryContext(ctx, args...)
 	defer rows.Close()
 	if !rows.Next() {
 		return nil
@@ -1,43 +1,238 @@
 package sdm
 
-// CreateTables creates all known table, breaks at first error
-func (m *Manager) CreateTables() (err error) {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
+import (
+	"database/sql"
+	"reflect"
+	"sort"
 
-	for t, n := range m.info {
-		_, err = m.drv.CreateTable(
-			m.Connection(),
-			n.Table,
-			t,
-			n.Fields,
-			n.Indexes,
-		)
-		if err != nil {
-			return
+	"github.com/Ronmi/sdm/driver"
+)
+
+// sortedInfo returns registered types in creation order: referenced tables
+// come before tables referencing them. Tables in a reference cycle are appended
+// by name at last. Caller must hold the lock.
+func (m *Manager) sortedInfo() []*tableInfo {
+	pending := make([]*tableInfo, 0, len(m.info))
+	for _, i := range m.info {
+		pending = append(pending, i)
+	}
+	sort.Slice(pending, func(a, b int) bool {
+		return pending[a].Table < pending[b].Table
+	})
+
+	// tables not registered are treated as existed
+	known := map[string]bool{}
+	for _, i := range pending {
+		known[i.Table] = true
+	}
+
+	ret := make([]*tableInfo, 0, len(pending))
+	created := map[string]bool{}
+	ready := func(info *tableInfo) bool {
+		for _, idx := range info.Indexes {
+			if idx.Type != driver.IndexTypeForeign {
+				continue
+			}
+			t := idx.Ref.Table
+			if t != info.Table && known[t] && !created[t] {
+				return false
+			}
 		}
+		return true
 	}
 
-	return
+	for len(pending) > 0 {
+		rest := pending[:0]
+		for _, i := range pending {
+			if ready(i) {
+				ret = append(ret, i)
+				created[i.Table] = true
+				continue
+			}
+			rest = append(rest, i)
+		}
+
+		if len(rest) == len(pending) {
+			// reference cycle
+			return append(ret, rest...)
+		}
+		pending = rest
+	}
+
+	return ret
+}
+
+// CreateTables creates all known table
+//
+// Tables are created in order of foreign key references, and by table name
+// if not related, so the order is always same.
+//
+// It tries all tables even if some of them failed, and reports them with
+// *ErrCreateTables. Tables referencing a failed one are skipped with
+// ErrRefNotCreated.
+func (m *Manager) CreateTables() error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.createTables(m.drv.CreateTable)
 }
 
 // CreateTablesNotExist creates all known table only if table yet created
+//
+// Tables are created in order of foreign key references. Errors are reported
+// like CreateTables.
+//
+// If previous names are specified by RegisterWas or "was=" option, and the
+// driver implements driver.Inspector and driver.Migrator, existing tables and
+// columns are renamed before creating.
 func (m *Manager) CreateTablesNotExist() (err error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
 
-	for t, n := range m.info {
-		_, err = m.drv.CreateTableNotExist(
-			m.Connection(),
-			n.Table,
-			t,
-			n.Fields,
-			n.Indexes,
-		)
+	if err = m.applyRenames(); err != nil {
+		return
+	}
+
+	return m.createTables(m.drv.CreateTableNotExist)
+}
+
+// createTables creates tables with f in creation order. Caller must hold the
+// lock.
+func (m *Manager) createTables(
+	f func(db *sql.DB, name string, typ reflect.Type, cols []driver.Column, idx []driver.Index) (sql.Result, error),
+) error {
+	var created []string
+	failed := map[string]error{}
+	for _, n := range m.sortedInfo() {
+		if err := refFailed(n, failed); err != nil {
+			failed[n.Table] = err
+			continue
+		}
+
+		_, err := f(m.Connection(), n.Table, n.Type, n.Fields, n.Indexes)
 		if err != nil {
-			return
+			failed[n.Table] = err
+			continue
 		}
+		created = append(created, n.Table)
+	}
+
+	if len(failed) > 0 {
+		return &ErrCreateTables{Created: created, Failed: failed}
 	}
+	return nil
+}
 
+// refFailed returns ErrRefNotCreated if info references a failed table
+func refFailed(info *tableInfo, failed map[string]error) error {
+	for _, idx := range info.Indexes {
+		if idx.Type != driver.IndexTypeForeign || idx.Ref.Table == info.Table {
+			continue
+		}
+		if _, ok := failed[idx.Ref.Table]; ok {
+			return ErrRefNotCreated
+		}
+	}
+	return nil
+}
+
+// DropTables drops all known table in reversed creation order, so tables
+// referencing others are dropped first.
+//
+// It tries all tables even if some of them failed, and reports them with
+// *ErrDropTables. Dropped tables are returned in dropping order.
+func (m *Manager) DropTables() (dropped []string, err error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	infos := m.sortedInfo()
+	failed := map[string]error{}
+	for x := len(infos) - 1; x >= 0; x-- {
+		n := infos[x]
+		if _, e := m.drv.DropTable(m.Connection(), n.Table); e != nil {
+			failed[n.Table] = e
+			continue
+		}
+		dropped = append(dropped, n.Table)
+	}
+
+	if len(failed) > 0 {
+		err = &ErrDropTables{Dropped: dropped, Failed: failed}
+	}
 	return
 }
+
+// CreateTableNotExist creates table of a registered type only if table yet
+// created.
+// It returns *ErrNotRegistered if type is not registered and auto register is
+// not enabled.
+func (m *Manager) CreateTableNotExist(typ interface{}) error {
+	info, err := m.lookupInfoOf(typ)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.drv.CreateTableNotExist(
+		m.Connection(),
+		info.Table,
+		info.Type,
+		info.Fields,
+		info.Indexes,
+	)
+	return err
+}
+
+// hasRenames determins if any registered table or column has previous name.
+// Caller must hold the lock.
+func (m *Manager) hasRenames() bool {
+	for _, info := range m.info {
+		if len(info.Was) > 0 {
+			return true
+		}
+		for _, c := range info.Fields {
+			if c.Was != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyRenames renames tables and columns found with previous names. Caller
+// must hold the lock.
+func (m *Manager) applyRenames() error {
+	if !m.hasRenames() {
+		return nil
+	}
+	insp, ok := m.drv.(driver.Inspector)
+	if !ok {
+		return nil
+	}
+	mig, ok := m.drv.(driver.Migrator)
+	if !ok {
+		return nil
+	}
+
+	live, err := m.inspect(insp)
+	if err != nil {
+		return err
+	}
+
+	var tables, cols []string
+	for _, info := range m.sortedInfo() {
+		d, err := live.diff(info)
+		if err != nil {
+			return err
+		}
+		t, c := renameStmts(mig, info, d)
+		tables = append(tables, t...)
+		cols = append(cols, c...)
+	}
+
+	for _, s := range append(tables, cols...) {
+		if _, err := live.db.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
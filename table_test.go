@@ -1,6 +1,12 @@
 package sdm
 
-import "testing"
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+)
 
 type testTable1 struct {
 	A int `sdm:"a"`
@@ -59,3 +65,167 @@ func TestCreateTablesNotExist(t *testing.T) {
 		t.Fatalf("failed to insert into table 3: %s", err)
 	}
 }
+
+func TestCreateTablesFailed(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE "testfkgroup" (id INTEGER)`); err != nil {
+		t.Fatalf("cannot create table: %s", err)
+	}
+	m := New(db, "sqlite3")
+	m.Reg(testFKGroup{}, testFKMember{}, testFKPost{}, testTable1{})
+
+	err := m.CreateTables()
+	var e *ErrCreateTables
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrCreateTables, got %v", err)
+	}
+	if expect := []string{"testtable1"}; !reflect.DeepEqual(e.Created, expect) {
+		t.Errorf("expected %v created, got %v", expect, e.Created)
+	}
+	if len(e.Failed) != 3 {
+		t.Errorf("expected 3 tables failed, got %+v", e.Failed)
+	}
+	for _, n := range []string{"testfkmember", "testfkpost"} {
+		if !errors.Is(e.Failed[n], ErrRefNotCreated) {
+			t.Errorf("expected %s is skipped, got %v", n, e.Failed[n])
+		}
+	}
+}
+
+type testFKGroup struct {
+	ID   int    `sdm:"id,ai"`
+	Name string `sdm:"name"`
+}
+
+type testFKMember struct {
+	ID      int    `sdm:"id,ai"`
+	GroupID int    `sdm:"group_id,fk_member_group=testfkgroup.id,ondelete=cascade"`
+	Name    string `sdm:"name"`
+}
+
+type testFKPost struct {
+	ID       int `sdm:"id,ai"`
+	MemberID int `sdm:"member_id,fk_post_member=testfkmember.id,ondelete=set_null,onupdate=cascade"`
+}
+
+func TestForeignKey(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`PRAGMA foreign_keys = ON`); err != nil {
+		t.Fatalf("cannot enable foreign keys: %s", err)
+	}
+	m := New(db, "sqlite3")
+	// register in reversed order, so creation order must be sorted
+	m.Reg(testFKPost{}, testFKMember{}, testFKGroup{})
+
+	info := m.getInfo(reflect.TypeOf(testFKPost{}))
+	expect := driver.Index{
+		Type: driver.IndexTypeForeign,
+		Name: "post_member",
+		Cols: []string{"member_id"},
+		Ref: &driver.ForeignKey{
+			Table:    "testfkmember",
+			Cols:     []string{"id"},
+			OnDelete: "SET NULL",
+			OnUpdate: "CASCADE",
+		},
+	}
+	if len(info.Indexes) < 1 || !reflect.DeepEqual(info.Indexes[0], expect) {
+		t.Errorf("expected foreign key %+v, got %+v", expect, info.Indexes)
+	}
+
+	order := []string{}
+	for _, i := range m.sortedInfo() {
+		order = append(order, i.Table)
+	}
+	if e := []string{"testfkgroup", "testfkmember", "testfkpost"}; !reflect.DeepEqual(order, e) {
+		t.Errorf("expected creation order %v, got %v", e, order)
+	}
+
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	g := &testFKGroup{Name: "g"}
+	if _, err := m.Insert(g); err != nil {
+		t.Fatalf("cannot insert group: %s", err)
+	}
+	if _, err := m.Insert(testFKMember{GroupID: g.ID + 1, Name: "m"}); err == nil {
+		t.Errorf("expected foreign key violation")
+	}
+	if _, err := m.Insert(testFKMember{GroupID: g.ID, Name: "m"}); err != nil {
+		t.Fatalf("cannot insert member: %s", err)
+	}
+	if _, err := m.Delete(g); err != nil {
+		t.Fatalf("cannot delete group: %s", err)
+	}
+	var cnt int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM testfkmember`).Scan(&cnt); err != nil {
+		t.Fatalf("cannot count members: %s", err)
+	}
+	if cnt != 0 {
+		t.Errorf("expected members are deleted in cascade, got %d", cnt)
+	}
+}
+
+func TestForeignKeyInvalid(t *testing.T) {
+	m := New(nil, "sqlite3")
+	cases := map[string]interface{}{
+		"no table": struct {
+			A int `sdm:"a,fk_x=id"`
+		}{},
+		"bad action": struct {
+			A int `sdm:"a,fk_x=t.id,ondelete=explode"`
+		}{},
+		"action without fk": struct {
+			A int `sdm:"a,ondelete=cascade"`
+		}{},
+	}
+	for name, c := range cases {
+		if err := m.RegE(c); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s: expected ErrInvalidOption, got %v", name, err)
+		}
+	}
+
+	err := m.RegE(struct {
+		A int `sdm:"a,fk_x=t1.id"`
+		B int `sdm:"b,fk_x=t2.id"`
+	}{})
+	if !errors.Is(err, ErrIndexConflict) {
+		t.Errorf("expected ErrIndexConflict, got %v", err)
+	}
+}
+
+func TestDropTables(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`PRAGMA foreign_keys = ON`); err != nil {
+		t.Fatalf("cannot enable foreign keys: %s", err)
+	}
+	m := New(db, "sqlite3")
+	m.Reg(testFKGroup{}, testFKMember{}, testFKPost{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	dropped, err := m.DropTables()
+	if err != nil {
+		t.Fatalf("cannot drop tables: %s", err)
+	}
+	if e := []string{"testfkpost", "testfkmember", "testfkgroup"}; !reflect.DeepEqual(dropped, e) {
+		t.Errorf("expected dropping order %v, got %v", e, dropped)
+	}
+
+	dropped, err = m.DropTables()
+	if len(dropped) != 0 {
+		t.Errorf("expected nothing dropped, got %v", dropped)
+	}
+	var e *ErrDropTables
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrDropTables, got %v", err)
+	}
+	if len(e.Failed) != 3 {
+		t.Errorf("expected 3 tables failed, got %+v", e.Failed)
+	}
+}
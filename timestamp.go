@@ -0,0 +1,147 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// now returns current time from Manager.Now, normalized by driver if possible
+func (m *Manager) now() time.Time {
+	t := time.Now()
+	if m.Now != nil {
+		t = m.Now()
+	}
+
+	if n, ok := m.drv.(driver.TimeNormalizer); ok {
+		t = n.NormalizeTime(t)
+	}
+	return t
+}
+
+// isTimestampType determins if t can be used as created/updated column
+func isTimestampType(t reflect.Type) bool {
+	return t == timeType || t == nullTimeType
+}
+
+// setTime sets time.Time or *time.Time field
+func setTime(f reflect.Value, t time.Time) {
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.ValueOf(&t))
+		return
+	}
+	f.Set(reflect.ValueOf(t))
+}
+
+// isZeroTime determins if time.Time or *time.Time field is not set
+func isZeroTime(f reflect.Value) bool {
+	if f.Kind() == reflect.Ptr {
+		return f.IsNil() || f.Elem().Interface().(time.Time).IsZero()
+	}
+	return f.Interface().(time.Time).IsZero()
+}
+
+// copyPath replaces every pointer along index in v, which must be settable, with
+// a pointer to a copy, so setting the field does not write through to the
+// struct v is copied from. Nil pointers are left for fieldByIndex to allocate.
+func copyPath(v reflect.Value, index []int) {
+	for _, i := range index[:len(index)-1] {
+		v = v.Field(i)
+		if v.Kind() != reflect.Ptr {
+			continue
+		}
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		v.Set(cp)
+		v = cp.Elem()
+	}
+}
+
+// stamp returns a copy of data (as pointer) with timestamp columns set to t.
+// Created column is set only if it is zero.
+//
+// Embedded pointers leading to timestamp columns are copied too, data is never
+// modified. It is returned as-is if no column needs to be stamped.
+func (m *Manager) stamp(info *tableInfo, data interface{}, t time.Time, created bool) interface{} {
+	created = created && info.Created != ""
+	if !created && info.Updated == "" {
+		return data
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+	cp := reflect.New(v.Type())
+	cp.Elem().Set(v)
+
+	if created {
+		idx := info.Defs[info.Created].Index
+		copyPath(cp.Elem(), idx)
+		if f, _ := fieldByIndex(cp.Elem(), idx, true); isZeroTime(f) {
+			setTime(f, t)
+		}
+	}
+	if info.Updated != "" {
+		idx := info.Defs[info.Updated].Index
+		copyPath(cp.Elem(), idx)
+		f, _ := fieldByIndex(cp.Elem(), idx, true)
+		setTime(f, t)
+	}
+
+	return cp.Interface()
+}
+
+// Stamp fills created (if zero) and updated columns of data, which must be a
+// pointer to registered struct, like Insert does.
+//
+// Queries built by BuildSQL or Val/ValIns are not stamped, call it before
+// generating values for hand-written upserts:
+//
+//     m.Stamp(&data)
+//     qstr := m.BuildSQL(data, `REPLACE INTO %table% (%cols%) VALUES (%vals%)`, driver.QInsert)
+//     m.Exec(qstr, m.ValIns(data)...)
+func (m *Manager) Stamp(data interface{}) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("sdm: need reference to stamp data")
+	}
+
+	info, err := m.lookupInfo(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	stamped := m.stamp(info, data, m.now(), true)
+	m.writeBack(info, data, stamped, info.Created, info.Updated)
+	return nil
+}
+
+// writeBack copies specified columns from stamped copy to data if data is a
+// pointer
+func (m *Manager) writeBack(info *tableInfo, data, stamped interface{}, cols ...string) {
+	if data == stamped {
+		return
+	}
+	dst := reflect.ValueOf(data)
+	if dst.Kind() != reflect.Ptr {
+		return
+	}
+	dst = dst.Elem()
+	src := reflect.ValueOf(stamped).Elem()
+
+	for _, c := range cols {
+		if c == "" {
+			continue
+		}
+		idx := info.Defs[c].Index
+		f, ok := fieldByIndex(dst, idx, true)
+		if !ok || !f.CanSet() {
+			continue
+		}
+		sf, _ := fieldByIndex(src, idx, false)
+		f.Set(sf)
+	}
+}
@@ -0,0 +1,205 @@
+package sdm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testtimestamp struct {
+	ID        int        `sdm:"id,ai"`
+	Name      string     `sdm:"name"`
+	CreatedAt time.Time  `sdm:"created_at,created"`
+	UpdatedAt *time.Time `sdm:"updated_at,updated"`
+}
+
+func TestTimestampRegister(t *testing.T) {
+	m := New(nil, "sqlite3")
+	err := m.RegE(struct {
+		C int `sdm:"c,created"`
+	}{})
+	if !errors.Is(err, ErrTimestampType) {
+		t.Errorf("expected ErrTimestampType, got %v", err)
+	}
+
+	err = m.RegE(struct {
+		U1 time.Time `sdm:"u1,updated"`
+		U2 time.Time `sdm:"u2,updated"`
+	}{})
+	if !errors.Is(err, ErrMultipleTimestamp) {
+		t.Errorf("expected ErrMultipleTimestamp, got %v", err)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	for _, mode := range []string{"time", "int", "string"} {
+		t.Run(mode, func(t *testing.T) {
+			db := newdb(t)
+			db.SetMaxOpenConns(1)
+			m := New(db, "sqlite3:time="+mode)
+			m.Reg(testtimestamp{})
+			if err := m.CreateTables(); err != nil {
+				t.Fatalf("cannot create tables: %s", err)
+			}
+
+			now := time.Date(2020, 1, 2, 3, 4, 5, 678, time.Local)
+			m.Now = func() time.Time { return now }
+
+			load := func(id int) (ret testtimestamp) {
+				if err := m.LoadSimple(&ret, id); err != nil {
+					t.Fatalf("cannot load: %s", err)
+				}
+				return
+			}
+			check := func(msg string, expect testtimestamp) {
+				actual := load(expect.ID)
+				if actual.CreatedAt != expect.CreatedAt {
+					t.Errorf("%s: created_at: expected %v, got %v", msg, expect.CreatedAt, actual.CreatedAt)
+				}
+				if actual.UpdatedAt == nil || expect.UpdatedAt == nil || *actual.UpdatedAt != *expect.UpdatedAt {
+					t.Errorf("%s: updated_at: expected %v, got %v", msg, expect.UpdatedAt, actual.UpdatedAt)
+				}
+			}
+
+			data := &testtimestamp{Name: "a"}
+			if _, err := m.Insert(data); err != nil {
+				t.Fatalf("cannot insert: %s", err)
+			}
+			if data.CreatedAt.IsZero() || data.UpdatedAt == nil {
+				t.Fatalf("expected timestamps are filled, got %+v", data)
+			}
+			check("insert", *data)
+
+			created := data.CreatedAt
+			now = now.Add(time.Hour)
+			data.Name = "b"
+			data.CreatedAt = time.Time{} // should not be written
+			if _, err := m.UpdateByPK(data); err != nil {
+				t.Fatalf("cannot update: %s", err)
+			}
+			data.CreatedAt = created
+			if !data.UpdatedAt.After(created) {
+				t.Errorf("expected updated_at is increased, got %v", data.UpdatedAt)
+			}
+			check("update by pk", *data)
+
+			now = now.Add(time.Hour)
+			if _, err := m.Update(data, `id=?`, data.ID); err != nil {
+				t.Fatalf("cannot update: %s", err)
+			}
+			check("update", *data)
+
+			now = now.Add(time.Hour)
+			if _, err := m.UpdateColumns(data, []string{"name"}, `id=?`, data.ID); err != nil {
+				t.Fatalf("cannot update columns: %s", err)
+			}
+			check("update columns", *data)
+
+			// created_at is kept if specified
+			b := m.BulkInsert(testtimestamp{})
+			rows := []*testtimestamp{{Name: "c", CreatedAt: created}, {Name: "d"}}
+			b.Add(rows[0], rows[1])
+			if _, err := m.RunBulk(b); err != nil {
+				t.Fatalf("cannot run bulk insert: %s", err)
+			}
+			rows[0].ID, rows[1].ID = 2, 3
+			for _, r := range rows {
+				if r.UpdatedAt == nil {
+					t.Errorf("expected updated_at is filled")
+				}
+				check("bulk insert", *r)
+			}
+			if rows[0].CreatedAt != created {
+				t.Errorf("expected created_at is kept, got %v", rows[0].CreatedAt)
+			}
+		})
+	}
+}
+
+func TestBulkInsertWriteBack(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3")
+	m.Reg(testtimestamp{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	data := &testtimestamp{Name: "a"}
+	b := m.BulkInsert(testtimestamp{})
+	b.Add(data)
+	b.Make()
+	if !data.CreatedAt.IsZero() || data.UpdatedAt != nil {
+		t.Fatalf("expected Make does not modify data, got %+v", data)
+	}
+
+	if _, err := db.Exec(`DROP TABLE testtimestamp`); err != nil {
+		t.Fatalf("cannot drop table: %s", err)
+	}
+	if _, err := m.RunBulk(b); err == nil {
+		t.Fatal("expected bulk insert fails")
+	}
+	if !data.CreatedAt.IsZero() || data.UpdatedAt != nil {
+		t.Errorf("expected data is untouched after failure, got %+v", data)
+	}
+
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	if _, err := m.RunBulk(b); err != nil {
+		t.Fatalf("cannot run bulk insert: %s", err)
+	}
+	if data.CreatedAt.IsZero() || data.UpdatedAt == nil {
+		t.Errorf("expected timestamps are filled, got %+v", data)
+	}
+}
+
+type testtimes struct {
+	Created time.Time `sdm:"created,created"`
+	Updated time.Time `sdm:"updated,updated"`
+}
+
+type testtsinline struct {
+	ID    int        `sdm:"id,ai"`
+	Times *testtimes `sdm:"t_,inline"`
+}
+
+func TestTimestampPointerPath(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3:time=int")
+	m.Reg(testtsinline{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	now := time.Unix(1577934245, 0)
+	m.Now = func() time.Time { return now }
+
+	// passed by value, shared sub-struct must not be modified
+	data := testtsinline{Times: &testtimes{}}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+	if !data.Times.Created.IsZero() || !data.Times.Updated.IsZero() {
+		t.Errorf("expected data is not modified, got %+v", data.Times)
+	}
+
+	var actual testtsinline
+	if err := m.LoadSimple(&actual, 1); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual.Times == nil || !actual.Times.Created.Equal(now) || !actual.Times.Updated.Equal(now) {
+		t.Errorf("expected timestamps are written, got %+v", actual.Times)
+	}
+
+	stamp := &testtsinline{}
+	if err := m.Stamp(stamp); err != nil {
+		t.Fatalf("cannot stamp: %s", err)
+	}
+	if stamp.Times == nil || !stamp.Times.Created.Equal(now) || !stamp.Times.Updated.Equal(now) {
+		t.Errorf("expected timestamps are filled, got %+v", stamp.Times)
+	}
+	if err := m.Stamp(*stamp); err == nil {
+		t.Error("expected error when stamping non-pointer")
+	}
+}
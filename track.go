@@ -0,0 +1,194 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	sqlDriver "database/sql/driver"
+	"errors"
+	"reflect"
+)
+
+// snapshot holds column values of tracked data
+type snapshot struct {
+	info *tableInfo
+	vals map[string]interface{}
+}
+
+// snapshotVal converts value returned by fieldVal into comparable form
+//
+// Valuers are resolved, pointers are dereferenced and byte slices are copied,
+// so later modification to the struct does not affect the snapshot.
+func snapshotVal(v interface{}) interface{} {
+	if valuer, ok := v.(sqlDriver.Valuer); ok {
+		x, err := valuer.Value()
+		if err != nil {
+			return v
+		}
+		v = x
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if b, ok := rv.Interface().([]byte); ok {
+		return append([]byte(nil), b...)
+	}
+	return rv.Interface()
+}
+
+func (m *Manager) takeSnapshot(info *tableInfo, v reflect.Value) *snapshot {
+	ret := &snapshot{
+		info: info,
+		vals: make(map[string]interface{}, len(info.Fields)),
+	}
+	for _, f := range info.Fields {
+		ret.vals[f.Name] = snapshotVal(m.fieldVal(v, f))
+	}
+
+	return ret
+}
+
+// Track records current values of data, which must be a pointer to registered
+// struct. UpdateChanged uses the record to find out modified columns.
+//
+// Call it right after loading data with Rows.Scan, QueryRow or others, so the
+// record is what you loaded from db.
+//
+//     rows.Scan(&member)
+//     m.Track(&member)
+//     member.Name = "new name"
+//     m.UpdateChanged(&member) // UPDATE ... SET name=? WHERE pk=?
+//
+// The record is kept in Manager, keyed by the pointer, until UpdateChanged
+// succeeds or Untrack is called. Tracking same pointer again replaces it.
+func (m *Manager) Track(data interface{}) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("sdm: need reference to track data")
+	}
+
+	info, err := m.lookupInfo(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	s := m.takeSnapshot(info, v.Elem())
+	m.trackLock.Lock()
+	defer m.trackLock.Unlock()
+	m.tracked[data] = s
+	return nil
+}
+
+// Untrack removes the record of data taken by Track
+func (m *Manager) Untrack(data interface{}) {
+	if reflect.ValueOf(data).Kind() != reflect.Ptr {
+		return
+	}
+	m.trackLock.Lock()
+	defer m.trackLock.Unlock()
+	delete(m.tracked, data)
+}
+
+func (m *Manager) getSnapshot(data interface{}) (*snapshot, error) {
+	if v := reflect.ValueOf(data); v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, ErrNotTracked
+	}
+
+	m.trackLock.Lock()
+	defer m.trackLock.Unlock()
+	s, ok := m.tracked[data]
+	if !ok {
+		return nil, ErrNotTracked
+	}
+	return s, nil
+}
+
+// Changed returns columns modified since data is tracked.
+//
+// ErrNotTracked is returned if data is not tracked.
+func (m *Manager) Changed(data interface{}) ([]string, error) {
+	s, err := m.getSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.changed(s, reflect.ValueOf(data).Elem()), nil
+}
+
+func (m *Manager) changed(s *snapshot, v reflect.Value) (ret []string) {
+	for _, f := range s.info.Fields {
+		cur := snapshotVal(m.fieldVal(v, f))
+		if !reflect.DeepEqual(cur, s.vals[f.Name]) {
+			ret = append(ret, f.Name)
+		}
+	}
+	return
+}
+
+// UpdateChanged updates modified columns of tracked data, using primary key
+// (can be composite) recorded by Track as condition.
+//
+// A result with zero affected row is returned without touching db if nothing
+// is changed. The record is released after a successful update, call Track
+// again to keep tracking.
+//
+// ErrNotTracked is returned if data is not tracked, and ErrNoPrimaryKey if type
+// has no primary key. Version column is handled like Update.
+func (m *Manager) UpdateChanged(data interface{}) (sql.Result, error) {
+	return m.UpdateChangedContext(context.Background(), data)
+}
+
+// UpdateChangedContext is context-aware version of UpdateChanged
+func (m *Manager) UpdateChangedContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return m.updateChanged(ctx, m.db, data)
+}
+
+func (m *Manager) updateChanged(ctx context.Context, c conn, data interface{}) (sql.Result, error) {
+	s, err := m.getSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	info := s.info
+	if info.PKIndex < 0 {
+		return nil, ErrNoPrimaryKey
+	}
+
+	cols := m.changed(s, reflect.ValueOf(data).Elem())
+	for _, c := range []string{info.Ver, info.Created, info.Updated} {
+		if c != "" {
+			cols = withoutCol(cols, c)
+		}
+	}
+	if len(cols) == 0 {
+		return sqlDriver.RowsAffected(0), nil
+	}
+
+	// match row with recorded primary key, so it works even if pk is modified
+	pkCols := info.Indexes[info.PKIndex].Cols
+	condVals := make([]interface{}, len(pkCols))
+	for x, col := range pkCols {
+		condVals[x] = s.vals[col]
+	}
+
+	stamped := m.stamp(info, data, m.now(), false)
+	qstr, vals, err := m.makeUpdateSQL(info, reflect.Indirect(reflect.ValueOf(stamped)), cols, true, m.pkWhere(info, m.Placeholders()), condVals)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.ExecContext(ctx, qstr, vals...)
+	if res, err = m.checkVer(info, data, res, err); err != nil {
+		return res, err
+	}
+	m.writeBack(info, data, stamped, info.Updated)
+
+	m.Untrack(data)
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateColumns(t *testing.T) {
+	m := initpkdb(t)
+	if _, err := m.Insert(testCompositePK{A: 1, B: "b", Score: 0.1}); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	// simulate another service modified score
+	if _, err := m.Connection().Exec(`UPDATE "testcompositepk" SET score=0.5`); err != nil {
+		t.Fatalf("cannot update score: %s", err)
+	}
+
+	note := "note"
+	data := testCompositePK{A: 1, B: "b", Score: 0.1, Note: &note}
+	qstr, vals, err := m.makeUpdateColumns(data, []string{"note"}, `a=? AND b=?`, []interface{}{1, "b"})
+	if err != nil {
+		t.Fatalf("cannot generate sql: %s", err)
+	}
+	expect := `UPDATE "testcompositepk" SET "note"=? WHERE a=? AND b=?`
+	if qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+	if l := len(vals); l != 3 {
+		t.Errorf("expected 3 values, got %d", l)
+	}
+
+	if _, err = m.UpdateColumns(data, []string{"note"}, `a=? AND b=?`, 1, "b"); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+
+	var actual testCompositePK
+	if err := m.QueryRow(&actual, `SELECT %cols% FROM %table% WHERE a=1 AND b='b'`); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual.Score != 0.5 || actual.Note == nil || *actual.Note != note {
+		t.Errorf("unexpected result: %+v", actual)
+	}
+
+	if _, err = m.UpdateColumns(data, []string{"nope"}, `a=1`); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+	if _, err = m.UpdateColumns(data, nil, `a=1`); !errors.Is(err, ErrNoColumn) {
+		t.Errorf("expected ErrNoColumn, got %v", err)
+	}
+}
+
+func TestUpdateChanged(t *testing.T) {
+	m := initpkdb(t)
+	if _, err := m.Insert(testCompositePK{A: 1, B: "b", Score: 0.1}); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	var data testCompositePK
+	if err := m.QueryRow(&data, `SELECT %cols% FROM %table% WHERE a=1 AND b='b'`); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+
+	if _, err := m.UpdateChanged(nil); err != ErrNotTracked {
+		t.Errorf("expected ErrNotTracked, got %v", err)
+	}
+	if _, err := m.UpdateChanged(&data); err != ErrNotTracked {
+		t.Errorf("expected ErrNotTracked before tracking, got %v", err)
+	}
+	if err := m.Track(data); err == nil {
+		t.Errorf("expected error when tracking non-pointer")
+	}
+	if err := m.Track(&data); err != nil {
+		t.Fatalf("cannot track: %s", err)
+	}
+	if _, err := initpkdb(t).UpdateChanged(&data); err != ErrNotTracked {
+		t.Errorf("expected ErrNotTracked with another manager, got %v", err)
+	}
+
+	res, err := m.UpdateChanged(&data)
+	if err != nil {
+		t.Fatalf("cannot update unchanged data: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 0 {
+		t.Errorf("expected nothing updated, got %d", n)
+	}
+
+	// simulate another service modified score
+	if _, err := m.Connection().Exec(`UPDATE "testcompositepk" SET score=0.5`); err != nil {
+		t.Fatalf("cannot update score: %s", err)
+	}
+
+	note := "note"
+	data.Note = &note
+	data.B = "c"
+	cols, err := m.Changed(&data)
+	if err != nil {
+		t.Fatalf("cannot get changed columns: %s", err)
+	}
+	if expect := []string{"b", "note"}; !reflect.DeepEqual(cols, expect) {
+		t.Errorf("expected changed columns %v, got %v", expect, cols)
+	}
+
+	if res, err = m.UpdateChanged(&data); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("expected 1 row updated, got %d", n)
+	}
+
+	var actual testCompositePK
+	if err := m.QueryRow(&actual, `SELECT %cols% FROM %table% WHERE a=1 AND b='c'`); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual.Score != 0.5 || actual.Note == nil || *actual.Note != note {
+		t.Errorf("unexpected result: %+v", actual)
+	}
+
+	// record is released after update
+	if _, err := m.Changed(&data); err != ErrNotTracked {
+		t.Errorf("expected ErrNotTracked after update, got %v", err)
+	}
+
+	// modifying the value pointed by field is also a change
+	if err := m.Track(&data); err != nil {
+		t.Fatalf("cannot track: %s", err)
+	}
+	note2 := "note"
+	data.Note = &note2
+	if cols, _ = m.Changed(&data); len(cols) != 0 {
+		t.Errorf("expected nothing changed, got %v", cols)
+	}
+	*data.Note = "changed"
+	if cols, _ = m.Changed(&data); !reflect.DeepEqual(cols, []string{"note"}) {
+		t.Errorf("expected note changed, got %v", cols)
+	}
+
+	m.Untrack(&data)
+	if _, err := m.UpdateChanged(&data); err != ErrNotTracked {
+		t.Errorf("expected ErrNotTracked after untracking, got %v", err)
+	}
+}
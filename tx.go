@@ -1,6 +1,7 @@
 package sdm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -21,6 +22,16 @@ func (tx *Tx) SQLIn(arr interface{}) (ret string) {
 	return tx.m.SQLIn(arr)
 }
 
+// Driver is a wrapper for Manager.Driver()
+func (tx *Tx) Driver() driver.Driver {
+	return tx.m.Driver()
+}
+
+// Placeholders is a wrapper for Manager.Placeholders()
+func (tx *Tx) Placeholders() *Placeholders {
+	return tx.m.Placeholders()
+}
+
 // Val is a wrapper for Manager.Val()
 func (tx *Tx) Val(data interface{}) []interface{} {
 	return tx.m.Val(data)
@@ -34,27 +45,32 @@ func (tx *Tx) ValIns(data interface{}) []interface{} {
 // Query makes SQL query and proxies it
 // It panics if type is not registered and auto register is not enabled.
 func (tx *Tx) Query(typ interface{}, qstr string, args ...interface{}) *Rows {
-	dbrows, err := tx.tx.Query(qstr, args...)
-	if err != nil {
-		t := reflect.Indirect(reflect.ValueOf(typ)).Type()
-		f := tx.m.getInfo(t).Defs
-
-		return &Rows{
-			nil,
-			f,
-			[]string{},
-			err,
-			t,
-			tx.m.drv,
-		}
-	}
+	return tx.QueryContext(context.Background(), typ, qstr, args...)
+}
+
+// QueryContext is context-aware version of Query
+func (tx *Tx) QueryContext(ctx context.Context, typ interface{}, qstr string, args ...interface{}) *Rows {
+	return tx.m.query(ctx, tx.tx, typ, qstr, args)
+}
 
-	return tx.m.Proxify(dbrows, typ)
+// QueryE is like Query, but returns *ErrNotRegistered instead of panicking.
+func (tx *Tx) QueryE(typ interface{}, qstr string, args ...interface{}) (*Rows, error) {
+	return tx.QueryContextE(context.Background(), typ, qstr, args...)
+}
+
+// QueryContextE is context-aware version of QueryE
+func (tx *Tx) QueryContextE(ctx context.Context, typ interface{}, qstr string, args ...interface{}) (*Rows, error) {
+	return tx.m.queryE(ctx, tx.tx, typ, qstr, args)
 }
 
 // QueryRow makes SQL query and proxies it, but allowing you to read only first row
 func (tx *Tx) QueryRow(data interface{}, qstr string, args ...interface{}) error {
-	rows := tx.Query(data, qstr, args...)
+	return tx.QueryRowContext(context.Background(), data, qstr, args...)
+}
+
+// QueryRowContext is context-aware version of QueryRow
+func (tx *Tx) QueryRowContext(ctx context.Context, data interface{}, qstr string, args ...interface{}) error {
+	rows := tx.QueryContext(ctx, data, qstr, args...)
 	defer rows.Close()
 
 	if !rows.Next() {
@@ -67,22 +83,90 @@ func (tx *Tx) QueryRow(data interface{}, qstr string, args ...interface{}) error
 // Prepare wraps sql.Tx.Prepare
 // It panics if type is not registered and auto register is not enabled.
 func (tx *Tx) Prepare(data interface{}, qstr string) (*Stmt, error) {
+	return tx.PrepareContext(context.Background(), data, qstr)
+}
+
+// PrepareContext wraps sql.Tx.PrepareContext
+// It panics if type is not registered and auto register is not enabled.
+func (tx *Tx) PrepareContext(ctx context.Context, data interface{}, qstr string) (*Stmt, error) {
 	t := reflect.Indirect(reflect.ValueOf(data)).Type()
 	f := tx.m.getInfo(t).Defs
-	return tx.m.prepare(tx.tx.Prepare, data, qstr, t, f, nil)
+	return tx.m.prepare(ctx, tx.tx, data, tx.m.rebind(qstr), t, f, nil)
 }
 
 // Prepare wraps sdm.Manager.PrepareSQL
 func (tx *Tx) PrepareSQL(data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error) {
-	qstr := tx.m.BuildSQL(data, tmpl, qType)
-	t := reflect.Indirect(reflect.ValueOf(data)).Type()
-	info := tx.m.getInfo(t)
-	cols := make([]string, len(info.Fields))
-	for x, c := range info.Fields {
-		cols[x] = c.Name
-	}
+	return tx.PrepareSQLContext(context.Background(), data, tmpl, qType)
+}
+
+// PrepareSQLContext wraps sdm.Manager.PrepareSQLContext
+func (tx *Tx) PrepareSQLContext(ctx context.Context, data interface{}, tmpl string, qType driver.QuotingType) (*Stmt, error) {
+	return tx.m.prepareSQL(ctx, tx.tx, data, tmpl, qType)
+}
 
-	return tx.m.prepare(tx.tx.Prepare, data, qstr, t, info.Defs, cols)
+// Exec wraps sql.Tx.Exec
+func (tx *Tx) Exec(qstr string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), qstr, args...)
+}
+
+// ExecContext wraps sql.Tx.ExecContext
+func (tx *Tx) ExecContext(ctx context.Context, qstr string, args ...interface{}) (sql.Result, error) {
+	return tx.tx.ExecContext(ctx, tx.m.rebind(qstr), args...)
+}
+
+// Select is a wrapper for Manager.Select()
+func (tx *Tx) Select(typ interface{}) *SelectBuilder {
+	return tx.m.selectBuilder(tx.tx, typ)
+}
+
+// FindByExample is a wrapper for Manager.FindByExample()
+func (tx *Tx) FindByExample(dst, example interface{}, cols ...string) error {
+	return tx.FindByExampleContext(context.Background(), dst, example, cols...)
+}
+
+// FindByExampleContext is a wrapper for Manager.FindByExampleContext()
+func (tx *Tx) FindByExampleContext(ctx context.Context, dst, example interface{}, cols ...string) error {
+	return tx.m.selectByExample(tx.tx, example, cols).AllContext(ctx, dst)
+}
+
+// CountByExample is a wrapper for Manager.CountByExample()
+func (tx *Tx) CountByExample(example interface{}, cols ...string) (int64, error) {
+	return tx.CountByExampleContext(context.Background(), example, cols...)
+}
+
+// CountByExampleContext is a wrapper for Manager.CountByExampleContext()
+func (tx *Tx) CountByExampleContext(ctx context.Context, example interface{}, cols ...string) (int64, error) {
+	return tx.m.selectByExample(tx.tx, example, cols).CountContext(ctx)
+}
+
+// LoadByExample is a wrapper for Manager.LoadByExample()
+func (tx *Tx) LoadByExample(data interface{}, cols ...string) error {
+	return tx.LoadByExampleContext(context.Background(), data, cols...)
+}
+
+// LoadByExampleContext is a wrapper for Manager.LoadByExampleContext()
+func (tx *Tx) LoadByExampleContext(ctx context.Context, data interface{}, cols ...string) error {
+	return tx.m.selectByExample(tx.tx, data, cols).OneContext(ctx, data)
+}
+
+// NamedQuery is a wrapper for Manager.NamedQuery()
+func (tx *Tx) NamedQuery(data interface{}, qstr string) *Rows {
+	return tx.NamedQueryContext(context.Background(), data, qstr)
+}
+
+// NamedQueryContext is a wrapper for Manager.NamedQueryContext()
+func (tx *Tx) NamedQueryContext(ctx context.Context, data interface{}, qstr string) *Rows {
+	return tx.m.namedQuery(ctx, tx.tx, data, qstr)
+}
+
+// NamedExec is a wrapper for Manager.NamedExec()
+func (tx *Tx) NamedExec(qstr string, arg interface{}) (sql.Result, error) {
+	return tx.NamedExecContext(context.Background(), qstr, arg)
+}
+
+// NamedExecContext is a wrapper for Manager.NamedExecContext()
+func (tx *Tx) NamedExecContext(ctx context.Context, qstr string, arg interface{}) (sql.Result, error) {
+	return tx.m.namedExec(ctx, tx.tx, qstr, arg)
 }
 
 // Insert inserts data into table.
@@ -90,26 +174,117 @@ func (tx *Tx) PrepareSQL(data interface{}, tmpl string, qType driver.QuotingType
 //
 // It will skip columns with "ai" tag
 func (tx *Tx) Insert(data interface{}) (sql.Result, error) {
-	qstr, vals := tx.m.makeInsert(data)
-	res, err := tx.tx.Exec(qstr, vals...)
-	if err == nil {
-		tx.m.tryFillPK(data, res)
-	}
-	return res, err
+	return tx.InsertContext(context.Background(), data)
+}
+
+// InsertContext is context-aware version of Insert
+func (tx *Tx) InsertContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.insert(ctx, tx.tx, data)
 }
 
 // Update updates data in db.
 // It panics if type is not registered and auto register is not enabled.
 func (tx *Tx) Update(data interface{}, where string, whereargs ...interface{}) (sql.Result, error) {
-	qstr, vals := tx.m.makeUpdate(data, where, whereargs)
-	return tx.tx.Exec(qstr, vals...)
+	return tx.UpdateContext(context.Background(), data, where, whereargs...)
+}
+
+// UpdateContext is context-aware version of Update
+func (tx *Tx) UpdateContext(ctx context.Context, data interface{}, where string, whereargs ...interface{}) (sql.Result, error) {
+	return tx.m.update(ctx, tx.tx, data, where, whereargs)
+}
+
+// UpdateColumns updates specified columns in db, see Manager.UpdateColumns
+func (tx *Tx) UpdateColumns(data interface{}, cols []string, where string, whereargs ...interface{}) (sql.Result, error) {
+	return tx.UpdateColumnsContext(context.Background(), data, cols, where, whereargs...)
+}
+
+// UpdateColumnsContext is context-aware version of UpdateColumns
+func (tx *Tx) UpdateColumnsContext(ctx context.Context, data interface{}, cols []string, where string, whereargs ...interface{}) (sql.Result, error) {
+	return tx.m.updateColumns(ctx, tx.tx, data, cols, where, whereargs)
+}
+
+// UpdateChanged updates modified columns of tracked data, see Manager.UpdateChanged
+//
+// The record is released even if the transaction is rolled back later, call
+// Manager.Track again in that case.
+func (tx *Tx) UpdateChanged(data interface{}) (sql.Result, error) {
+	return tx.UpdateChangedContext(context.Background(), data)
+}
+
+// UpdateChangedContext is context-aware version of UpdateChanged
+func (tx *Tx) UpdateChangedContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.updateChanged(ctx, tx.tx, data)
 }
 
 // Delete deletes data in db.
 // It panics if type is not registered and auto register is not enabled.
 func (tx *Tx) Delete(data interface{}) (sql.Result, error) {
-	qstr, vals := tx.m.makeDelete(data)
-	return tx.tx.Exec(qstr, vals...)
+	return tx.DeleteContext(context.Background(), data)
+}
+
+// DeleteContext is context-aware version of Delete
+func (tx *Tx) DeleteContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.delete(ctx, tx.tx, data)
+}
+
+// HardDelete deletes data in db ignoring soft delete, see Manager.HardDelete
+func (tx *Tx) HardDelete(data interface{}) (sql.Result, error) {
+	return tx.HardDeleteContext(context.Background(), data)
+}
+
+// HardDeleteContext is context-aware version of HardDelete
+func (tx *Tx) HardDeleteContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.hardDelete(ctx, tx.tx, data)
+}
+
+// Find selects rows excluding soft deleted ones, see Manager.Find
+func (tx *Tx) Find(typ interface{}, where string, args ...interface{}) *Rows {
+	return tx.FindContext(context.Background(), typ, where, args...)
+}
+
+// FindContext is context-aware version of Find
+func (tx *Tx) FindContext(ctx context.Context, typ interface{}, where string, args ...interface{}) *Rows {
+	return tx.m.query(ctx, tx.tx, typ, tx.m.makeFind(typ, where, false), args)
+}
+
+// FindWithDeleted is like Find, but includes soft deleted rows
+func (tx *Tx) FindWithDeleted(typ interface{}, where string, args ...interface{}) *Rows {
+	return tx.FindWithDeletedContext(context.Background(), typ, where, args...)
+}
+
+// FindWithDeletedContext is context-aware version of FindWithDeleted
+func (tx *Tx) FindWithDeletedContext(ctx context.Context, typ interface{}, where string, args ...interface{}) *Rows {
+	return tx.m.query(ctx, tx.tx, typ, tx.m.makeFind(typ, where, true), args)
+}
+
+// UpdateByPK updates data in db, see Manager.UpdateByPK
+func (tx *Tx) UpdateByPK(data interface{}) (sql.Result, error) {
+	return tx.UpdateByPKContext(context.Background(), data)
+}
+
+// UpdateByPKContext is context-aware version of UpdateByPK
+func (tx *Tx) UpdateByPKContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.updateByPK(ctx, tx.tx, data)
+}
+
+// DeleteByPK deletes data in db, see Manager.DeleteByPK
+func (tx *Tx) DeleteByPK(data interface{}) (sql.Result, error) {
+	return tx.DeleteByPKContext(context.Background(), data)
+}
+
+// DeleteByPKContext is context-aware version of DeleteByPK
+func (tx *Tx) DeleteByPKContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.deleteByPK(ctx, tx.tx, data)
+}
+
+// Save inserts or updates data, see Manager.Save
+func (tx *Tx) Save(data interface{}) (sql.Result, error) {
+	return tx.SaveContext(context.Background(), data)
+}
+
+// SaveContext is context-aware version of Save
+func (tx *Tx) SaveContext(ctx context.Context, data interface{}) (sql.Result, error) {
+	return tx.m.save(ctx, tx.tx, data)
 }
 
 // Rollback is just same as sql.Tx.Rollback
@@ -124,8 +299,13 @@ func (tx *Tx) Commit() error {
 
 // Stmt is just same as sql.Tx.Stmt, buf for sdm
 func (tx *Tx) Stmt(s *Stmt) *Stmt {
+	return tx.StmtContext(context.Background(), s)
+}
+
+// StmtContext is just same as sql.Tx.StmtContext, buf for sdm
+func (tx *Tx) StmtContext(ctx context.Context, s *Stmt) *Stmt {
 	return &Stmt{
-		stmt:    tx.tx.Stmt(s.stmt),
+		stmt:    tx.tx.StmtContext(ctx, s.stmt),
 		def:     s.def,
 		t:       s.t,
 		drv:     s.drv,
@@ -141,6 +321,20 @@ func (tx *Tx) Tx() *sql.Tx {
 // RunBulk executes a bulk operation. It does not return a result when
 // success and panics if bulk implementation goes wrong.
 func (tx *Tx) RunBulk(b Bulk) (sql.Result, error) {
+	return tx.RunBulkContext(context.Background(), b)
+}
+
+// RunBulkContext is context-aware version of RunBulk
+func (tx *Tx) RunBulkContext(ctx context.Context, b Bulk) (sql.Result, error) {
+	ret, err := tx.runBulk(ctx, b)
+	if w, ok := b.(bulkWriter); ok && err == nil {
+		w.writeBack()
+	}
+	return ret, err
+}
+
+// runBulk executes the bulk operation without filling values back
+func (tx *Tx) runBulk(ctx context.Context, b Bulk) (sql.Result, error) {
 	if b.Len() < 1 {
 		return nil, nil
 	}
@@ -149,12 +343,22 @@ func (tx *Tx) RunBulk(b Bulk) (sql.Result, error) {
 		panic(fmt.Sprintf("Bulk implementation goes wrong: number of query string (%d) and parameters (%d) does not match", x, y))
 	}
 
+	_, isDelete := b.(*bulkDelete)
+	var affected int64
 	for idx, q := range qstr {
 		v := vals[idx]
-		if res, err := tx.Tx().Exec(q, v...); err != nil {
+		res, err := tx.tx.ExecContext(ctx, q, v...)
+		if err != nil {
 			return res, err
 		}
+		if isDelete && tx.m.StrictDelete {
+			n, _ := res.RowsAffected()
+			affected += n
+		}
 	}
 
+	if isDelete && tx.m.StrictDelete && affected == 0 {
+		return nil, ErrNoRowsAffected
+	}
 	return nil, nil
 }
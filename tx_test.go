@@ -1,6 +1,7 @@
 package sdm
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -64,3 +65,25 @@ func TestTxRollback(t *testing.T) {
 		t.Errorf("There should be no result after rollback, but we got %d", cnt)
 	}
 }
+
+func TestTxContext(t *testing.T) {
+	_, m := initdb(t)
+	ti, _ := time.Parse("2006-01-02 15:04:05 -0700", "2016-07-05 08:00:00 +0800")
+	data := testok{1, 2, 3, "context", ti}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := m.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Cannot create transaction with context: %s", err)
+	}
+
+	if _, err := tx.InsertContext(ctx, data); err != nil {
+		t.Fatalf("Error inserting data with context: %s", err)
+	}
+
+	// canceling context rolls back the transaction
+	cancel()
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Expected commit to fail after context is canceled")
+	}
+}
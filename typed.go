@@ -0,0 +1,229 @@
+package sdm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// TypedRows is type-safe version of Rows, T must be a registered struct type
+// or pointer to it.
+//
+// It is named TypedRows since Rows is already taken by the untyped one.
+//
+//     rows := sdm.QueryRows[Member](m, `SELECT %cols% FROM %table%`)
+//     defer rows.Close()
+//     for rows.Next() {
+//         member := rows.Value()
+//     }
+//     if err := rows.Err(); err != nil {
+//         // error handling
+//     }
+type TypedRows[T any] struct {
+	rows *Rows
+	cur  T
+	ptr  bool
+	err  error
+}
+
+// Next reads next record, returns false if no more records or an error occurred
+func (r *TypedRows[T]) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+
+	var v T
+	dst := interface{}(&v)
+	if r.ptr {
+		rv := reflect.ValueOf(&v).Elem()
+		rv.Set(reflect.New(rv.Type().Elem()))
+		dst = v
+	}
+	if err := r.rows.Scan(dst); err != nil {
+		return false
+	}
+	r.cur = v
+	return true
+}
+
+// Value returns current record
+func (r *TypedRows[T]) Value() T {
+	return r.cur
+}
+
+// Err proxies Rows.Err
+func (r *TypedRows[T]) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close proxies Rows.Close
+func (r *TypedRows[T]) Close() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Close()
+}
+
+// All reads rest of records. It is caller's response to close the TypedRows.
+func (r *TypedRows[T]) All() (ret []T, err error) {
+	ret = []T{}
+	for r.Next() {
+		ret = append(ret, r.cur)
+	}
+
+	return ret, r.Err()
+}
+
+// QueryRows makes SQL query with Executable.Query, and wraps result in TypedRows
+//
+// You can use "%table%" and "%cols%" in qstr, see Manager.Query for detail.
+//
+// If e is *Manager or *Tx, *ErrNotRegistered is reported by TypedRows.Err
+// instead of panicking.
+func QueryRows[T any](e Executable, qstr string, args ...interface{}) *TypedRows[T] {
+	ret, typ := typedRows[T](e)
+	if ret.err == nil {
+		ret.rows = e.Query(typ, qstr, args...)
+	}
+	return ret
+}
+
+// QueryRowsContext is context-aware version of QueryRows
+func QueryRowsContext[T any](ctx context.Context, e ExecutableContext, qstr string, args ...interface{}) *TypedRows[T] {
+	ret, typ := typedRows[T](e)
+	if ret.err == nil {
+		ret.rows = e.QueryContext(ctx, typ, qstr, args...)
+	}
+	return ret
+}
+
+// typedRows resolves T (dereferencing pointer type) and creates an empty
+// TypedRows, with a value of resolved type to pass to Executable.Query
+func typedRows[T any](e Executable) (ret *TypedRows[T], typ interface{}) {
+	ret = &TypedRows[T]{}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if ret.ptr = t.Kind() == reflect.Ptr; ret.ptr {
+		t = t.Elem()
+	}
+
+	if m, err := managerOf(e); err == nil {
+		if _, ret.err = m.lookupInfo(t); ret.err != nil {
+			return
+		}
+	}
+
+	return ret, reflect.New(t).Interface()
+}
+
+// QueryAll makes SQL query and reads all records
+func QueryAll[T any](e Executable, qstr string, args ...interface{}) ([]T, error) {
+	rows := QueryRows[T](e, qstr, args...)
+	defer rows.Close()
+	return rows.All()
+}
+
+// QueryAllContext is context-aware version of QueryAll
+func QueryAllContext[T any](ctx context.Context, e ExecutableContext, qstr string, args ...interface{}) ([]T, error) {
+	rows := QueryRowsContext[T](ctx, e, qstr, args...)
+	defer rows.Close()
+	return rows.All()
+}
+
+// QueryOne makes SQL query and reads first record, sql.ErrNoRows is returned
+// if nothing found.
+func QueryOne[T any](e Executable, qstr string, args ...interface{}) (ret T, err error) {
+	return readOne(QueryRows[T](e, qstr, args...))
+}
+
+// QueryOneContext is context-aware version of QueryOne
+func QueryOneContext[T any](ctx context.Context, e ExecutableContext, qstr string, args ...interface{}) (ret T, err error) {
+	return readOne(QueryRowsContext[T](ctx, e, qstr, args...))
+}
+
+func readOne[T any](rows *TypedRows[T]) (ret T, err error) {
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = sql.ErrNoRows
+		}
+		return
+	}
+
+	return rows.Value(), nil
+}
+
+// LoadByPK loads a record by primary key, sql.ErrNoRows is returned if nothing
+// found. Values of pk must follow the order of columns in primary key.
+//
+// e must be *Manager or *Tx.
+func LoadByPK[T any](e Executable, pk ...interface{}) (ret T, err error) {
+	qstr, err := loadByPKSQL[T](e, pk)
+	if err != nil {
+		return
+	}
+	return QueryOne[T](e, qstr, pk...)
+}
+
+// LoadByPKContext is context-aware version of LoadByPK
+func LoadByPKContext[T any](ctx context.Context, e ExecutableContext, pk ...interface{}) (ret T, err error) {
+	qstr, err := loadByPKSQL[T](e, pk)
+	if err != nil {
+		return
+	}
+	return QueryOneContext[T](ctx, e, qstr, pk...)
+}
+
+func loadByPKSQL[T any](e Executable, pk []interface{}) (qstr string, err error) {
+	m, err := managerOf(e)
+	if err != nil {
+		return
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	info, err := m.lookupInfo(t)
+	if err != nil {
+		return
+	}
+	if info.PKIndex < 0 {
+		return "", ErrNoPrimaryKey
+	}
+
+	cols := info.Indexes[info.PKIndex].Cols
+	if len(cols) != len(pk) {
+		return "", errors.New("sdm: number of primary key values mismatch")
+	}
+
+	p := m.Placeholders()
+	cond := make([]string, len(cols))
+	for x, c := range cols {
+		ft := t.FieldByIndex(info.Defs[c].Index).Type
+		cond[x] = m.drv.Col(info.Table, c, driver.QWhere) + `=` + p.Next(ft)
+	}
+
+	if c := m.aliveCond(info); c != "" {
+		cond = append(cond, c)
+	}
+
+	return `SELECT %cols% FROM %table% WHERE ` + strings.Join(cond, " AND "), nil
+}
+
+// managerOf finds the Manager behind an Executable
+func managerOf(e Executable) (*Manager, error) {
+	switch x := e.(type) {
+	case *Manager:
+		return x, nil
+	case *Tx:
+		return x.m, nil
+	}
+
+	return nil, errors.New("sdm: unsupported Executable implementation")
+}
@@ -0,0 +1,132 @@
+package sdm
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTyped(t *testing.T) {
+	_, m := initdb(t)
+	ti := time.Now().Round(time.Second)
+	for _, s := range []string{"a", "b", "c"} {
+		if _, err := m.Insert(testai{ExportString: s, ExportTime: ti}); err != nil {
+			t.Fatalf("cannot insert: %s", err)
+		}
+	}
+
+	t.Run("QueryAll", func(t *testing.T) {
+		arr, err := QueryAll[testai](m, `SELECT %cols% FROM %table% ORDER BY eint ASC`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if l := len(arr); l != 3 {
+			t.Fatalf("expected 3 records, got %d", l)
+		}
+		for x, s := range []string{"a", "b", "c"} {
+			if arr[x].ExportString != s || arr[x].ExportInt != x+1 {
+				t.Errorf("unexpected record #%d: %+v", x, arr[x])
+			}
+		}
+	})
+
+	t.Run("QueryOne", func(t *testing.T) {
+		data, err := QueryOne[testai](m, `SELECT %cols% FROM %table% WHERE estr=?`, "b")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if data.ExportInt != 2 {
+			t.Errorf("expected eint to be 2, got %d", data.ExportInt)
+		}
+
+		if _, err = QueryOne[testai](m, `SELECT %cols% FROM %table% WHERE estr=?`, "x"); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows, got %v", err)
+		}
+	})
+
+	t.Run("Rows", func(t *testing.T) {
+		rows := QueryRows[testai](m, `SELECT %cols% FROM %table% ORDER BY eint DESC`)
+		defer rows.Close()
+		cnt := 0
+		for rows.Next() {
+			cnt++
+			if v := rows.Value(); v.ExportInt != 4-cnt {
+				t.Errorf("unexpected record #%d: %+v", cnt, v)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if cnt != 3 {
+			t.Errorf("expected 3 records, got %d", cnt)
+		}
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		arr, err := QueryAll[*testai](m, `SELECT %cols% FROM %table% ORDER BY eint ASC`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if l := len(arr); l != 3 {
+			t.Fatalf("expected 3 records, got %d", l)
+		}
+		if arr[0] == arr[1] || arr[0].ExportString != "a" || arr[1].ExportString != "b" {
+			t.Errorf("unexpected records: %+v, %+v", arr[0], arr[1])
+		}
+
+		data, err := LoadByPK[*testai](m, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if data.ExportString != "b" {
+			t.Errorf("unexpected record: %+v", data)
+		}
+	})
+
+	t.Run("NotRegistered", func(t *testing.T) {
+		type unreg struct {
+			A int `sdm:"a"`
+		}
+		var e *ErrNotRegistered
+		if _, err := QueryAll[unreg](m, `SELECT %cols% FROM %table%`); !errors.As(err, &e) {
+			t.Errorf("expected *ErrNotRegistered, got %v", err)
+		}
+		if _, err := QueryOne[*unreg](m, `SELECT %cols% FROM %table%`); !errors.As(err, &e) {
+			t.Errorf("expected *ErrNotRegistered, got %v", err)
+		}
+		rows := QueryRows[unreg](m, `SELECT %cols% FROM %table%`)
+		if rows.Next() {
+			t.Error("expected Next to be false")
+		}
+		if err := rows.Close(); !errors.As(err, &e) {
+			t.Errorf("expected *ErrNotRegistered, got %v", err)
+		}
+	})
+
+	t.Run("LoadByPK", func(t *testing.T) {
+		tx, err := m.Begin()
+		if err != nil {
+			t.Fatalf("cannot begin transaction: %s", err)
+		}
+		defer tx.Rollback()
+
+		data, err := LoadByPK[testai](tx, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if data.ExportString != "c" || !data.ExportTime.Equal(ti) {
+			t.Errorf("unexpected record: %+v", data)
+		}
+
+		if _, err = LoadByPK[testai](tx, 4); err != sql.ErrNoRows {
+			t.Errorf("expected sql.ErrNoRows, got %v", err)
+		}
+		if _, err = LoadByPK[testok](tx, 1); err == nil {
+			t.Error("expected error when loading type without primary key")
+		}
+		if _, err = LoadByPK[testai](tx, 1, 2); err == nil {
+			t.Error("expected error when number of values mismatch")
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package sdm
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// ColumnDiff describes a column which differs from the registered one
+type ColumnDiff struct {
+	Expect driver.ColumnInfo // derived from registered type
+	Actual driver.ColumnInfo // found in database
+}
+
+// ColumnRename describes a column found with its previous name
+type ColumnRename struct {
+	From string
+	To   string
+}
+
+// TableDiff describes differences between a registered type and the table in
+// database. Index names are not compared, since some databases do not keep
+// them.
+type TableDiff struct {
+	Type           reflect.Type
+	Table          string
+	Missing        bool           // table does not exist
+	RenamedFrom    string         // table is found with previous name
+	RenamedColumns []ColumnRename // columns found with previous name
+	MissingColumns []string       // columns not found in database
+	ExtraColumns   []string       // columns not found in registered type
+	Columns        []ColumnDiff   // columns with different type or nullability
+	MissingIndexes []driver.Index // indexes not found in database
+	ExtraIndexes   []driver.Index // indexes not found in registered type
+}
+
+// Empty determins if the table matches registered type
+func (d TableDiff) Empty() bool {
+	return !d.Missing &&
+		d.RenamedFrom == "" &&
+		len(d.RenamedColumns) == 0 &&
+		len(d.MissingColumns) == 0 &&
+		len(d.ExtraColumns) == 0 &&
+		len(d.Columns) == 0 &&
+		len(d.MissingIndexes) == 0 &&
+		len(d.ExtraIndexes) == 0
+}
+
+func (d TableDiff) String() string {
+	if d.Missing {
+		return d.Table + ": table not found"
+	}
+
+	ret := []string{}
+	if d.RenamedFrom != "" {
+		ret = append(ret, "renamed from "+d.RenamedFrom)
+	}
+	for _, c := range d.RenamedColumns {
+		ret = append(ret, "column "+c.To+" renamed from "+c.From)
+	}
+	for _, c := range d.MissingColumns {
+		ret = append(ret, "missing column "+c)
+	}
+	for _, c := range d.ExtraColumns {
+		ret = append(ret, "extra column "+c)
+	}
+	for _, c := range d.Columns {
+		ret = append(ret, "column "+c.Expect.Name+" should be "+
+			colDesc(c.Expect)+", got "+colDesc(c.Actual))
+	}
+	for _, i := range d.MissingIndexes {
+		ret = append(ret, "missing "+indexKey(i))
+	}
+	for _, i := range d.ExtraIndexes {
+		ret = append(ret, "extra "+indexKey(i))
+	}
+
+	return d.Table + ": " + strings.Join(ret, ", ")
+}
+
+// SchemaDiff is the report of Manager.Verify
+type SchemaDiff []TableDiff
+
+func (d SchemaDiff) String() string {
+	ret := make([]string, len(d))
+	for k, t := range d {
+		ret[k] = t.String()
+	}
+	return strings.Join(ret, "\n")
+}
+
+func colDesc(c driver.ColumnInfo) string {
+	if c.Nullable {
+		return c.Type + " NULL"
+	}
+	return c.Type + " NOT NULL"
+}
+
+// indexKey identifies an index by its type and columns
+func indexKey(i driver.Index) string {
+	ret := i.Type + "(" + strings.Join(i.Cols, ",") + ")"
+	if i.Ref != nil {
+		ret += " " + i.Ref.Table + "(" + strings.Join(i.Ref.Cols, ",") + ")" + i.Ref.Actions()
+	}
+	return ret
+}
+
+// Verify compares registered types with tables in database, including column
+// types, nullability, primary key, indexes and foreign keys. Only tables which
+// differ are reported. Default values and column comments are not compared.
+//
+// It returns ErrNoInspector if the driver does not implement driver.Inspector.
+//
+//     diff, err := m.Verify()
+//     if err != nil || len(diff) > 0 {
+//         log.Fatalf("schema mismatch: %v\n%s", err, diff)
+//     }
+func (m *Manager) Verify() (ret SchemaDiff, err error) {
+	insp, ok := m.drv.(driver.Inspector)
+	if !ok {
+		return nil, ErrNoInspector
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	live, err := m.inspect(insp)
+	if err != nil {
+		return
+	}
+
+	for _, info := range m.sortedInfo() {
+		d, err := live.diff(info)
+		if err != nil {
+			return ret, err
+		}
+		if !d.Empty() {
+			ret = append(ret, d)
+		}
+	}
+
+	return
+}
+
+// liveSchema holds what needed to compare registered types with database
+type liveSchema struct {
+	insp    driver.Inspector
+	db      *sql.DB
+	exists  map[string]bool   // tables in database
+	renamed map[string]string // previous table name => registered one
+}
+
+// inspect lists tables in database, and finds renamed tables. Caller must hold
+// the lock.
+func (m *Manager) inspect(insp driver.Inspector) (*liveSchema, error) {
+	db := m.Connection()
+	tables, err := insp.Tables(db)
+	if err != nil {
+		return nil, err
+	}
+	ret := &liveSchema{
+		insp:    insp,
+		db:      db,
+		exists:  map[string]bool{},
+		renamed: map[string]string{},
+	}
+	for _, t := range tables {
+		ret.exists[t] = true
+	}
+
+	for _, info := range m.info {
+		if ret.exists[info.Table] {
+			continue
+		}
+		for _, w := range info.Was {
+			if ret.exists[w] {
+				ret.renamed[w] = info.Table
+				break
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// diff compares a registered type with the table in database
+func (s *liveSchema) diff(info *tableInfo) (d TableDiff, err error) {
+	d = TableDiff{Type: info.Type, Table: info.Table}
+	table := info.Table
+	if !s.exists[table] {
+		for old, t := range s.renamed {
+			if t == table {
+				d.RenamedFrom = old
+				table = old
+			}
+		}
+		if d.RenamedFrom == "" {
+			d.Missing = true
+			return
+		}
+	}
+
+	cols, err := s.insp.Columns(s.db, table)
+	if err != nil {
+		return
+	}
+	idxes, err := s.insp.Indexes(s.db, table)
+	if err != nil {
+		return
+	}
+	expCols, expIdxes := s.insp.Expect(info.Type, info.Fields, info.Indexes)
+
+	diffColumns(&d, info, expCols, cols)
+
+	// indexes and foreign keys follow renamed tables and columns
+	colRenamed := map[string]string{}
+	for _, c := range d.RenamedColumns {
+		colRenamed[c.From] = c.To
+	}
+	for x, i := range idxes {
+		i.Cols = renameAll(i.Cols, colRenamed)
+		if i.Ref != nil {
+			ref := *i.Ref
+			if t, ok := s.renamed[ref.Table]; ok {
+				ref.Table = t
+			}
+			i.Ref = &ref
+		}
+		idxes[x] = i
+	}
+	diffIndexes(&d, expIdxes, idxes)
+	return
+}
+
+// renameAll returns a copy of names, with renamed ones replaced
+func renameAll(names []string, renamed map[string]string) []string {
+	ret := make([]string, len(names))
+	for x, n := range names {
+		ret[x] = n
+		if to, ok := renamed[n]; ok {
+			ret[x] = to
+		}
+	}
+	return ret
+}
+
+func diffColumns(d *TableDiff, info *tableInfo, expect, actual []driver.ColumnInfo) {
+	found := map[string]driver.ColumnInfo{}
+	for _, c := range actual {
+		found[c.Name] = c
+	}
+	known := map[string]bool{}
+
+	for _, e := range expect {
+		known[e.Name] = true
+		a, ok := found[e.Name]
+		if was := info.Defs[e.Name].Was; !ok && was != "" {
+			if a, ok = found[was]; ok {
+				known[was] = true
+				d.RenamedColumns = append(d.RenamedColumns, ColumnRename{From: was, To: e.Name})
+				a.Name = e.Name
+			}
+		}
+		if !ok {
+			d.MissingColumns = append(d.MissingColumns, e.Name)
+			continue
+		}
+		if !strings.EqualFold(e.Type, a.Type) || e.Nullable != a.Nullable {
+			d.Columns = append(d.Columns, ColumnDiff{Expect: e, Actual: a})
+		}
+	}
+
+	for _, a := range actual {
+		if !known[a.Name] {
+			d.ExtraColumns = append(d.ExtraColumns, a.Name)
+		}
+	}
+}
+
+func diffIndexes(d *TableDiff, expect, actual []driver.Index) {
+	found := map[string]bool{}
+	for _, i := range actual {
+		found[indexKey(i)] = true
+	}
+	known := map[string]bool{}
+
+	for _, e := range expect {
+		k := indexKey(e)
+		known[k] = true
+		if !found[k] {
+			d.MissingIndexes = append(d.MissingIndexes, e)
+		}
+	}
+
+	for _, a := range actual {
+		if !known[indexKey(a)] {
+			d.ExtraIndexes = append(d.ExtraIndexes, a)
+		}
+	}
+}
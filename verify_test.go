@@ -0,0 +1,91 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+type testverify struct {
+	ID    int     `sdm:"id,ai"`
+	Name  string  `sdm:"name,size=32,uniq_name"`
+	Email string  `sdm:"email,idx_email"`
+	Memo  *string `sdm:"memo"`
+}
+
+func TestVerify(t *testing.T) {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3")
+	m.Reg(testverify{}, testFKGroup{}, testFKMember{}, testFKPost{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+
+	diff, err := m.Verify()
+	if err != nil {
+		t.Fatalf("cannot verify: %s", err)
+	}
+	if len(diff) != 0 {
+		t.Fatalf("expected no difference, got\n%s", diff)
+	}
+
+	schema := []string{
+		`DROP TABLE "testverify"`,
+		`CREATE TABLE "testverify" ("id" INTEGER NOT NULL,"name" TEXT NOT NULL,"memo" TEXT NOT NULL,"extra" TEXT)`,
+		`DROP TABLE "testfkpost"`,
+	}
+	for _, s := range schema {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("cannot modify schema: %s", err)
+		}
+	}
+
+	diff, err = m.Verify()
+	if err != nil {
+		t.Fatalf("cannot verify: %s", err)
+	}
+	expect := SchemaDiff{
+		{
+			Type:    reflect.TypeOf(testFKPost{}),
+			Table:   "testfkpost",
+			Missing: true,
+		},
+		{
+			Type:           reflect.TypeOf(testverify{}),
+			Table:          "testverify",
+			MissingColumns: []string{"email"},
+			ExtraColumns:   []string{"extra"},
+			Columns: []ColumnDiff{
+				{
+					Expect: driver.ColumnInfo{Name: "name", Type: "VARCHAR(32)"},
+					Actual: driver.ColumnInfo{Name: "name", Type: "TEXT"},
+				},
+				{
+					Expect: driver.ColumnInfo{Name: "memo", Type: "TEXT", Nullable: true},
+					Actual: driver.ColumnInfo{Name: "memo", Type: "TEXT"},
+				},
+			},
+			MissingIndexes: []driver.Index{
+				{Type: driver.IndexTypePrimary, Cols: []string{"id"}},
+				{Type: driver.IndexTypeUnique, Name: "name", Cols: []string{"name"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(diff, expect) {
+		t.Errorf("expected\n%+v\ngot\n%+v", expect, diff)
+	}
+	if diff.String() == "" {
+		t.Error("expected report in text")
+	}
+}
+
+func TestVerifyNoInspector(t *testing.T) {
+	driver.MySQLStub()
+	m := New(nil, "mysqlstub")
+	if _, err := m.Verify(); !errors.Is(err, ErrNoInspector) {
+		t.Errorf("expected ErrNoInspector, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package sdm
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/Ronmi/sdm/driver"
+)
+
+// withoutCol returns a copy of cols without col
+func withoutCol(cols []string, col string) []string {
+	ret := make([]string, 0, len(cols))
+	for _, c := range cols {
+		if c != col {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// verSet generates SET clause increasing version column
+func (m *Manager) verSet(info *tableInfo) string {
+	c := m.drv.Col(info.Table, info.Ver, driver.QUpdate)
+	return c + "=" + c + "+1"
+}
+
+// verCond generates WHERE clause matching current version of data
+func (m *Manager) verCond(info *tableInfo, v reflect.Value, p *Placeholders) (cond string, val interface{}) {
+	f := info.Defs[info.Ver]
+	cond = m.drv.Col(info.Table, info.Ver, driver.QWhere) + "=" +
+		p.Next(info.Type.FieldByIndex(f.Index).Type)
+	return cond, m.fieldVal(v, f)
+}
+
+// checkVer returns ErrStaleObject if versioned data is not updated, or
+// increases version field of data if it is a pointer
+func (m *Manager) checkVer(info *tableInfo, data interface{}, res sql.Result, err error) (sql.Result, error) {
+	if err != nil || info.Ver == "" {
+		return res, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return res, err
+	}
+	if n == 0 {
+		return res, ErrStaleObject
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr {
+		return res, nil
+	}
+	f, ok := fieldByIndex(v.Elem(), info.Defs[info.Ver].Index, false)
+	if !ok || !f.CanSet() {
+		return res, nil
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.SetInt(f.Int() + 1)
+	default:
+		f.SetUint(f.Uint() + 1)
+	}
+	return res, nil
+}
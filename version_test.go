@@ -0,0 +1,131 @@
+package sdm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testver struct {
+	ID   int    `sdm:"id,ai"`
+	Name string `sdm:"name"`
+	Ver  int    `sdm:"version,ver"`
+}
+
+func initverdb(t *testing.T) *Manager {
+	db := newdb(t)
+	db.SetMaxOpenConns(1)
+	m := New(db, "sqlite3")
+	m.Reg(testver{})
+	if err := m.CreateTables(); err != nil {
+		t.Fatalf("cannot create tables: %s", err)
+	}
+	return m
+}
+
+func TestVersionRegister(t *testing.T) {
+	m := New(nil, "sqlite3")
+	m.Reg(testver{})
+	if ver := m.getInfo(reflect.TypeOf(testver{})).Ver; ver != "version" {
+		t.Errorf("expected version column is version, got %s", ver)
+	}
+
+	err := m.RegE(struct {
+		V1 int `sdm:"v1,ver"`
+		V2 int `sdm:"v2,ver"`
+	}{})
+	if !errors.Is(err, ErrMultipleVer) {
+		t.Errorf("expected ErrMultipleVer, got %v", err)
+	}
+
+	err = m.RegE(struct {
+		V string `sdm:"v,ver"`
+	}{})
+	if !errors.Is(err, ErrVerType) {
+		t.Errorf("expected ErrVerType, got %v", err)
+	}
+}
+
+func TestVersionUpdate(t *testing.T) {
+	m := initverdb(t)
+	data := &testver{Name: "a"}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	qstr, vals, err := m.makeUpdate(data, `id=?`, []interface{}{data.ID})
+	if err != nil {
+		t.Fatalf("cannot generate sql: %s", err)
+	}
+	expect := `UPDATE "testver" SET "name"=?,"version"="version"+1 WHERE (id=?) AND "testver"."version"=?`
+	if qstr != expect {
+		t.Errorf("expected %s, got %s", expect, qstr)
+	}
+	if l := len(vals); l != 3 {
+		t.Errorf("expected 3 values, got %d", l)
+	}
+
+	stale := *data
+	data.Name = "b"
+	if _, err := m.Update(data, `id=?`, data.ID); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if data.Ver != 1 {
+		t.Errorf("expected version is increased to 1, got %d", data.Ver)
+	}
+
+	stale.Name = "c"
+	if _, err := m.Update(&stale, `id=?`, stale.ID); err != ErrStaleObject {
+		t.Errorf("expected ErrStaleObject, got %v", err)
+	}
+	if stale.Ver != 0 {
+		t.Errorf("expected version of stale object untouched, got %d", stale.Ver)
+	}
+
+	var actual testver
+	if err := m.QueryRow(&actual, `SELECT %cols% FROM %table% WHERE id=?`, data.ID); err != nil {
+		t.Fatalf("cannot load: %s", err)
+	}
+	if actual != *data {
+		t.Errorf("expected %+v, got %+v", *data, actual)
+	}
+}
+
+func TestVersionUpdateByPK(t *testing.T) {
+	m := initverdb(t)
+	data := &testver{Name: "a"}
+	if _, err := m.Insert(data); err != nil {
+		t.Fatalf("cannot insert: %s", err)
+	}
+
+	tx, err := m.Begin()
+	if err != nil {
+		t.Fatalf("cannot begin transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	stale := *data
+	data.Name = "b"
+	if _, err := tx.UpdateByPK(data); err != nil {
+		t.Fatalf("cannot update: %s", err)
+	}
+	if data.Ver != 1 {
+		t.Errorf("expected version is increased to 1, got %d", data.Ver)
+	}
+
+	if _, err := tx.UpdateByPK(stale); err != ErrStaleObject {
+		t.Errorf("expected ErrStaleObject, got %v", err)
+	}
+
+	// also works with dirty tracking
+	if err := m.Track(data); err != nil {
+		t.Fatalf("cannot track: %s", err)
+	}
+	data.Name = "c"
+	if _, err := tx.UpdateChanged(data); err != nil {
+		t.Fatalf("cannot update changed: %s", err)
+	}
+	if data.Ver != 2 {
+		t.Errorf("expected version is increased to 2, got %d", data.Ver)
+	}
+}